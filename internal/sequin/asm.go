@@ -0,0 +1,443 @@
+package sequin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+)
+
+var quoteOutput bool
+
+func encodeCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:     "encode [statements...]",
+		Aliases: []string{"asm"},
+		Short:   "Write the sequences described by mnemonics",
+		Long: `Write the sequences described by mnemonics, separated by semicolons or
+newlines. Statements start with the mnemonic of a sequence, as shown by
+sequin, followed by its arguments:
+
+  DECSET 1006 2004; SGR bold fg=red; TEXT "hi"; OSC8 https://charm.sh
+
+SGR takes attributes (bold, faint, italic, underline, blink, reverse, conceal,
+strike, reset, and their no- forms) and colors (fg=, bg=, and ul= followed by a
+name like red or bright-blue, a palette index, or #rrggbb). Control codes are
+written by name (CR, LF, BEL...), text is quoted, and CSI, ESC, OSC, DCS,
+APC, PM, and SOS take the raw contents of a sequence, quoted if it has semicolons:
+
+  CSI "1;31m"; OSC "0;title"
+
+Statements are read from the arguments, or from the standard input.`,
+		Example: `
+# Enable SGR mouse and bracketed paste, then print bold red text:
+sequin encode 'DECSET 1006 2004; SGR bold fg=red; TEXT "hi"' | sequin
+
+# Get the bytes as a Go string for a test:
+sequin asm -q 'CUP 10 20; EL'
+	`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src := strings.Join(args, "\n")
+			if len(args) == 0 {
+				in, err := io.ReadAll(cmd.InOrStdin())
+				if err != nil {
+					return err //nolint:wrapcheck
+				}
+				src = string(in)
+			}
+			out, err := assemble(src)
+			if err != nil {
+				return err
+			}
+			if quoteOutput {
+				out = strconv.Quote(out) + "\n"
+			}
+			_, err = io.WriteString(cmd.OutOrStdout(), out)
+			return err //nolint:wrapcheck
+		},
+	}
+	c.Flags().BoolVarP(&quoteOutput, "quote", "q", false, "write the sequences as a quoted Go string")
+	return c
+}
+
+// token is a word of a statement. Quoted words can hold any text.
+type token struct {
+	s      string
+	quoted bool
+}
+
+// assemble returns the sequences described by the statements in src.
+func assemble(src string) (string, error) {
+	stmts, err := tokenize(src)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, stmt := range stmts {
+		s, err := assembleStmt(stmt)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", err, joinTokens(stmt, " "))
+		}
+		b.WriteString(s)
+	}
+	return b.String(), nil
+}
+
+// tokenize splits src into statements and words. Parentheses and commas
+// separate words too, so that mnemonics like "CUP(10,20)" can be read back.
+func tokenize(src string) ([][]token, error) {
+	var stmts [][]token
+	var stmt []token
+	var cur token
+	var inWord bool
+	word := func() {
+		if inWord {
+			stmt = append(stmt, cur)
+		}
+		cur, inWord = token{}, false
+	}
+	end := func() {
+		word()
+		if len(stmt) > 0 {
+			stmts = append(stmts, stmt)
+		}
+		stmt = nil
+	}
+
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; c {
+		case ';', '\n':
+			end()
+		case ' ', '\t', '\r', '(', ')', ',':
+			word()
+		case '"':
+			j := i + 1
+			for ; j < len(src) && src[j] != '"'; j++ {
+				if src[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string: %s", src[i:])
+			}
+			s, err := strconv.Unquote(src[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s: %w", src[i:j+1], err)
+			}
+			cur.s += s
+			cur.quoted = true
+			inWord = true
+			i = j
+		default:
+			cur.s += string(c)
+			inWord = true
+		}
+	}
+	end()
+	return stmts, nil
+}
+
+var (
+	reNumbers = regexp.MustCompile(`^[0-9:]*$`)
+	reOsc     = regexp.MustCompile(`^OSC([0-9]+)$`)
+)
+
+// ctrlNames maps the names of control codes to their bytes.
+var ctrlNames = map[string]byte{
+	"NUL": ansi.NUL, "BEL": ansi.BEL, "BS": ansi.BS, "HT": ansi.HT,
+	"LF": ansi.LF, "VT": ansi.VT, "FF": ansi.FF, "CR": ansi.CR,
+	"SO": ansi.SO, "SI": ansi.SI, "ESC": ansi.ESC, "DEL": ansi.DEL,
+}
+
+//nolint:cyclop
+func assembleStmt(stmt []token) (string, error) {
+	if stmt[0].quoted {
+		// A quoted string on its own is text.
+		return joinTokens(stmt, " "), nil
+	}
+	name, args := strings.ToUpper(stmt[0].s), stmt[1:]
+	raw := joinTokens(args, "")
+
+	switch name {
+	case "TEXT":
+		return joinTokens(args, " "), nil
+	case "CSI":
+		return "\x1b[" + raw, nil
+	case "ESC":
+		if len(args) > 0 {
+			return "\x1b" + raw, nil
+		}
+	case "OSC":
+		return "\x1b]" + raw + "\a", nil
+	case "DCS":
+		return "\x1bP" + raw + "\x1b\\", nil
+	case "APC":
+		return "\x1b_" + raw + "\x1b\\", nil
+	case "PM":
+		return "\x1b^" + raw + "\x1b\\", nil
+	case "SOS":
+		return "\x1bX" + raw + "\x1b\\", nil
+	case "SGR":
+		return assembleSgr(args)
+	case "DECSET", "DECRST", "SM", "RM":
+		modes := make([]ansi.Mode, 0, len(args))
+		for _, arg := range args {
+			n, err := strconv.Atoi(arg.s)
+			if err != nil {
+				return "", fmt.Errorf("invalid mode %q", arg.s)
+			}
+			if name == "DECSET" || name == "DECRST" {
+				modes = append(modes, ansi.DECMode(n))
+			} else {
+				modes = append(modes, ansi.ANSIMode(n))
+			}
+		}
+		if name == "DECSET" || name == "SM" {
+			return ansi.SetMode(modes...), nil
+		}
+		return ansi.ResetMode(modes...), nil
+	case "CUP":
+		if len(args) > 2 { //nolint:mnd
+			return "", errors.New("CUP takes a row and a column, like CUP 10 20")
+		}
+		pos := []int{1, 1}
+		for i, arg := range args {
+			n, err := strconv.Atoi(arg.s)
+			if err != nil || n < 1 {
+				return "", fmt.Errorf("invalid position %q", arg.s)
+			}
+			pos[i] = n
+		}
+		return ansi.CursorPosition(pos[1], pos[0]), nil
+	case "SCS":
+		// The character set is needed, or the lowest command would be picked.
+		if len(args) != 2 { //nolint:mnd
+			return "", errors.New("SCS needs a set and a character set, like SCS G0 B")
+		}
+		g := strings.ToUpper(args[0].s)
+		if len(g) != 2 || g[0] != 'G' || g[1] < '0' || g[1] > '3' {
+			return "", fmt.Errorf("invalid set %q", args[0].s)
+		}
+		final := args[1].s
+		if len(final) != 1 {
+			return "", fmt.Errorf("invalid character set %q", final)
+		}
+		if _, ok := charsetNames[final[0]]; !ok {
+			return "", fmt.Errorf("invalid character set %q", final)
+		}
+		return "\x1b" + string('('+g[1]-'0') + final, nil
+	case "OSC0":
+		return ansi.SetIconNameWindowTitle(joinTokens(args, " ")), nil
+	case "OSC1":
+		return ansi.SetIconName(joinTokens(args, " ")), nil
+	case "OSC2":
+		return ansi.SetWindowTitle(joinTokens(args, " ")), nil
+	case "OSC8":
+		return assembleHyperlink(args), nil
+	case "XTGETTCAP":
+		caps := make([]string, len(args))
+		for i, arg := range args {
+			caps[i] = arg.s
+		}
+		return ansi.XTGETTCAP(caps...), nil
+	}
+
+	if c, ok := ctrlNames[name]; ok && len(args) == 0 {
+		return string(c), nil
+	}
+	if m := reOsc.FindStringSubmatch(name); m != nil {
+		if len(args) == 0 {
+			return "\x1b]" + m[1] + "\a", nil
+		}
+		parts := make([]string, len(args))
+		for i, arg := range args {
+			parts[i] = arg.s
+		}
+		return "\x1b]" + m[1] + ";" + strings.Join(parts, ";") + "\a", nil
+	}
+	if cmd, ok := lookupCsi(name); ok {
+		return assembleCsi(cmd, args)
+	}
+	if cmd, ok := escHandler.lookup(name); ok && len(args) == 0 {
+		s := "\x1b"
+		if i := cmd.Intermediate(); i != 0 {
+			s += string(i)
+		}
+		return s + string(cmd.Final()), nil
+	}
+	return "", errors.New("unknown mnemonic")
+}
+
+// lookup returns the command of the handler with the given mnemonic. When
+// several share it, the one with the lowest command wins.
+func (r registry) lookup(name string) (ansi.Cmd, bool) {
+	var cmds []int
+	for cmd, h := range r {
+		if h.name == name {
+			cmds = append(cmds, cmd)
+		}
+	}
+	if len(cmds) == 0 {
+		return 0, false
+	}
+	return ansi.Cmd(slices.Min(cmds)), true
+}
+
+// lookupCsi returns the command of the CSI handler, or alternate, with the
+// given mnemonic.
+func lookupCsi(name string) (ansi.Cmd, bool) {
+	if cmd, ok := csiHandlers.lookup(name); ok {
+		return cmd, true
+	}
+	return alternates(csiAlternates).lookup(name)
+}
+
+// assembleCsi writes a CSI sequence for the command, with numeric
+// parameters.
+func assembleCsi(cmd ansi.Cmd, args []token) (string, error) {
+	params := make([]string, len(args))
+	for i, arg := range args {
+		if !reNumbers.MatchString(arg.s) {
+			return "", fmt.Errorf("invalid parameter %q", arg.s)
+		}
+		params[i] = arg.s
+	}
+	s := "\x1b["
+	if p := cmd.Prefix(); p != 0 {
+		s += string(p)
+	}
+	s += strings.Join(params, ";")
+	if i := cmd.Intermediate(); i != 0 {
+		s += string(i)
+	}
+	return s + string(cmd.Final()), nil
+}
+
+// assembleHyperlink writes a hyperlink for the URL among the arguments. The
+// other arguments, like "id=1", are its parameters.
+func assembleHyperlink(args []token) string {
+	var url string
+	var hasURL bool
+	var params []string
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg.s, "url="):
+			url, hasURL = strings.TrimPrefix(arg.s, "url="), true
+		case !hasURL && !strings.Contains(arg.s, "="):
+			url, hasURL = arg.s, true
+		default:
+			params = append(params, arg.s)
+		}
+	}
+	return ansi.SetHyperlink(url, params...)
+}
+
+var sgrAttrs = map[string]func(ansi.Style) ansi.Style{
+	"reset":        ansi.Style.Reset,
+	"bold":         ansi.Style.Bold,
+	"faint":        ansi.Style.Faint,
+	"italic":       func(s ansi.Style) ansi.Style { return s.Italic(true) },
+	"underline":    func(s ansi.Style) ansi.Style { return s.Underline(true) },
+	"blink":        func(s ansi.Style) ansi.Style { return s.Blink(true) },
+	"rapid-blink":  func(s ansi.Style) ansi.Style { return s.RapidBlink(true) },
+	"reverse":      func(s ansi.Style) ansi.Style { return s.Reverse(true) },
+	"inverse":      func(s ansi.Style) ansi.Style { return s.Reverse(true) },
+	"conceal":      func(s ansi.Style) ansi.Style { return s.Conceal(true) },
+	"strike":       func(s ansi.Style) ansi.Style { return s.Strikethrough(true) },
+	"normal":       ansi.Style.Normal,
+	"no-italic":    ansi.Style.NoItalic,
+	"no-underline": ansi.Style.NoUnderline,
+	"no-blink":     ansi.Style.NoBlink,
+	"no-reverse":   ansi.Style.NoReverse,
+	"no-conceal":   ansi.Style.NoConceal,
+	"no-strike":    ansi.Style.NoStrikethrough,
+}
+
+var underlineStyles = map[string]ansi.Underline{
+	"none":   ansi.NoUnderlineStyle,
+	"single": ansi.SingleUnderlineStyle,
+	"double": ansi.DoubleUnderlineStyle,
+	"curly":  ansi.CurlyUnderlineStyle,
+	"dotted": ansi.DottedUnderlineStyle,
+	"dashed": ansi.DashedUnderlineStyle,
+}
+
+var colorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// assembleSgr writes an SGR sequence for the attributes and colors.
+func assembleSgr(args []token) (string, error) {
+	var s ansi.Style
+	for _, arg := range args {
+		key, value, hasValue := strings.Cut(strings.ToLower(arg.s), "=")
+		switch {
+		case reNumbers.MatchString(arg.s):
+			// Raw parameters are written as is.
+			s = append(s, arg.s)
+		case !hasValue && sgrAttrs[key] != nil:
+			s = sgrAttrs[key](s)
+		case key == "underline":
+			u, ok := underlineStyles[value]
+			if !ok {
+				return "", fmt.Errorf("invalid underline style %q", value)
+			}
+			s = s.UnderlineStyle(u)
+		case key == "fg", key == "bg", key == "ul":
+			c, err := parseColor(value)
+			if err != nil {
+				return "", err
+			}
+			switch key {
+			case "fg":
+				s = s.ForegroundColor(c)
+			case "bg":
+				s = s.BackgroundColor(c)
+			default:
+				s = s.UnderlineColor(c)
+			}
+		default:
+			return "", fmt.Errorf("invalid attribute %q", arg.s)
+		}
+	}
+	return s.String(), nil
+}
+
+// parseColor returns the color with the given name, palette index, or hex
+// value. The default color is nil.
+func parseColor(s string) (ansi.Color, error) {
+	if s == "default" {
+		return nil, nil
+	}
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		n, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 { //nolint:mnd
+			return nil, fmt.Errorf("invalid color %q", s)
+		}
+		return ansi.TrueColor(n), nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < 256 {
+		return ansi.IndexedColor(n), nil //nolint:gosec
+	}
+	name, bright := strings.CutPrefix(s, "bright-")
+	if i := slices.Index(colorNames, name); i >= 0 {
+		if bright {
+			i += 8
+		}
+		return ansi.BasicColor(i), nil //nolint:gosec
+	}
+	return nil, fmt.Errorf("invalid color %q", s)
+}
+
+// joinTokens returns the words joined by sep.
+func joinTokens(tokens []token, sep string) string {
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.s
+	}
+	return strings.Join(words, sep)
+}
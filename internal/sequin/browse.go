@@ -0,0 +1,366 @@
+package sequin
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var interactive bool
+
+// browseKinds are the kinds the browser can hide, toggled with the keys 1
+// to 9.
+var browseKinds = []string{"Text", "Ctrl", "CSI", "ESC", "OSC", "DCS", "APC", "PM", "SOS"}
+
+const browseHelp = "↑/↓ move · / search · n/N next/prev · 1-9 kinds · q quit"
+
+// browse shows the events in a full-screen browser. The stream started at
+// start, if known.
+func browse(w io.Writer, rec recording) error {
+	var events []event
+	decodeChunks(rec.chunks, func(e event) {
+		events = append(events, e)
+	})
+	f, err := newFilter()
+	if err != nil {
+		return err
+	}
+	b := newBrowser(newTheme(), events)
+	b.opts = flagOptions(rec.start)
+	b.screenWidth, b.screenHeight = rec.size()
+	b.apply(f)
+	_, err = tea.NewProgram(b, tea.WithOutput(w)).Run()
+	return err //nolint:wrapcheck
+}
+
+// browser is the model of the browser: a list of events on the left, and
+// the details of the selected one and the screen it left on the right.
+type browser struct {
+	t      theme
+	events []event
+	hidden map[string]bool
+
+	// opts are how the events are shown in the list.
+	opts printOptions
+
+	// match reports whether an event matches --match, if set.
+	match func(event) bool
+
+	// visible holds the indexes of the events shown, and cursor the
+	// selected one among them.
+	visible []int
+	cursor  int
+	top     int
+
+	// searching is set while typing the query, and origin is where the
+	// cursor was when the search started.
+	searching bool
+	query     string
+	origin    int
+	msg       string
+
+	// screen is the screen after the first applied events, which starts
+	// at the size of the recording.
+	screen                    *grid
+	applied                   int
+	screenWidth, screenHeight int
+
+	width, height int
+}
+
+func newBrowser(t theme, events []event) *browser {
+	b := &browser{
+		t:      t,
+		events: events,
+		hidden: map[string]bool{},
+		width:  defaultWidth,
+		height: defaultHeight,
+
+		screenWidth:  defaultWidth,
+		screenHeight: defaultHeight,
+	}
+	b.filter()
+	return b
+}
+
+func (b *browser) Init() tea.Cmd {
+	return nil
+}
+
+func (b *browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.width, b.height = msg.Width, msg.Height
+	case tea.KeyPressMsg:
+		if b.searching {
+			b.searchKey(msg)
+			break
+		}
+		b.msg = ""
+		switch key := msg.String(); key {
+		case "q", "ctrl+c":
+			return b, tea.Quit
+		case "up", "k":
+			b.move(-1)
+		case "down", "j":
+			b.move(1)
+		case "pgup":
+			b.move(-b.listHeight())
+		case "pgdown", "space":
+			b.move(b.listHeight())
+		case "home", "g":
+			b.move(-len(b.visible))
+		case "end", "G":
+			b.move(len(b.visible))
+		case "/":
+			b.searching, b.query, b.origin = true, "", b.cursor
+		case "n":
+			b.find(b.cursor+1, 1)
+		case "N":
+			b.find(b.cursor-1, -1)
+		default:
+			if len(key) == 1 && key[0] >= '1' && int(key[0]-'1') < len(browseKinds) {
+				kind := browseKinds[key[0]-'1']
+				b.hidden[kind] = !b.hidden[kind]
+				b.filter()
+			}
+		}
+	}
+	return b, nil
+}
+
+// searchKey handles a key while typing the query: the selection moves to the
+// first match from where the search started as the query changes.
+func (b *browser) searchKey(msg tea.KeyPressMsg) {
+	switch msg.String() {
+	case "enter":
+		b.searching = false
+		return
+	case "esc", "ctrl+c":
+		b.searching, b.query = false, ""
+		b.cursor = b.origin
+		b.scroll()
+		return
+	case "backspace":
+		if b.query != "" {
+			_, size := utf8.DecodeLastRuneInString(b.query)
+			b.query = b.query[:len(b.query)-size]
+		}
+	default:
+		if msg.Text == "" {
+			return
+		}
+		b.query += msg.Text
+	}
+	b.find(b.origin, 1)
+}
+
+// find selects the first event from the visible one at i, going in the
+// direction dir, that matches the query. The search is case-insensitive.
+func (b *browser) find(i, dir int) {
+	if b.query == "" {
+		return
+	}
+	query := strings.ToLower(b.query)
+	for ; i >= 0 && i < len(b.visible); i += dir {
+		if strings.Contains(strings.ToLower(b.events[b.visible[i]].String()), query) {
+			b.cursor = i
+			b.scroll()
+			b.msg = ""
+			return
+		}
+	}
+	b.msg = "no match for " + b.query
+}
+
+// apply starts the browser with the kinds --only and --exclude hide
+// hidden, and only the events that match --match.
+func (b *browser) apply(f filter) {
+	for _, kind := range browseKinds {
+		b.hidden[kind] = !f.keepKind(kind)
+	}
+	b.match = f.matches
+	b.filter()
+}
+
+// filter updates the visible events after the hidden kinds changed, keeping
+// the selection on the same event or the closest one before it.
+func (b *browser) filter() {
+	selected := -1
+	if b.cursor < len(b.visible) {
+		selected = b.visible[b.cursor]
+	}
+	b.visible = b.visible[:0]
+	b.cursor = 0
+	for i, e := range b.events {
+		if b.hidden[e.kind] || b.match != nil && !b.match(e) {
+			continue
+		}
+		if i <= selected {
+			b.cursor = len(b.visible)
+		}
+		b.visible = append(b.visible, i)
+	}
+	b.scroll()
+}
+
+// move moves the selection by n events.
+func (b *browser) move(n int) {
+	b.cursor = max(0, min(b.cursor+n, len(b.visible)-1))
+	b.scroll()
+}
+
+// scroll keeps the selection in the list.
+func (b *browser) scroll() {
+	h := b.listHeight()
+	if b.cursor < b.top {
+		b.top = b.cursor
+	}
+	if b.cursor >= b.top+h {
+		b.top = b.cursor - h + 1
+	}
+	b.top = max(0, min(b.top, len(b.visible)-h))
+}
+
+// listHeight is the number of events shown at once, between the bar with
+// the kinds and the status line.
+func (b *browser) listHeight() int {
+	return max(1, b.height-2) //nolint:mnd
+}
+
+func (b *browser) View() tea.View {
+	listWidth := b.width * 3 / 5 //nolint:mnd
+	paneWidth := b.width - listWidth - 1
+
+	var list []string
+	for i := b.top; i < min(b.top+b.listHeight(), len(b.visible)); i++ {
+		prefix := "  "
+		if i == b.cursor {
+			prefix = b.t.explanation.Bold(true).Render("› ")
+		}
+		list = append(list, prefix+b.row(b.events[b.visible[i]]))
+	}
+
+	var pane []string
+	if len(b.visible) > 0 {
+		pane = b.detail(b.events[b.visible[b.cursor]])
+	}
+
+	view := lipgloss.JoinVertical(
+		lipgloss.Left,
+		b.kindsBar(),
+		lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			block(list, listWidth, b.listHeight()),
+			" ",
+			block(pane, paneWidth, b.listHeight()),
+		),
+		b.statusLine(),
+	)
+	v := tea.NewView(view)
+	v.AltScreen = true
+	return v
+}
+
+// row returns the event on a single line, as printEvent writes it.
+func (b *browser) row(e event) string {
+	var s strings.Builder
+	printEvent(&s, b.t, e, b.opts)
+	line, _, _ := strings.Cut(s.String(), "\n")
+	return line
+}
+
+// detail returns the lines describing the event: its bytes, parameters,
+// explanation, and the screen once it is applied.
+func (b *browser) detail(e event) []string {
+	field := func(label, value string) string {
+		return b.t.sequence.Render(fmt.Sprintf("%-10s", label)) + b.t.explanation.Render(value)
+	}
+	lines := []string{
+		field("Kind", e.kind),
+		field("Offset", fmt.Sprintf("%08x, %d bytes", e.offset, len(e.seq))),
+	}
+	if e.stream != "" {
+		lines = append(lines, field("Time", fmt.Sprintf("%s %s", strings.TrimSpace(formatTime(e.at, b.opts.start)), streamNames[e.stream])))
+	}
+	lines = append(lines, field("Sequence", strings.TrimSpace(e.kind+" "+seqString(e.seq))))
+	if e.name != "" {
+		lines = append(lines, field("Mnemonic", e.mnemonic()))
+	}
+	if len(e.params) > 0 {
+		lines = append(lines, field("Params", sgrString(e.params)))
+	}
+	lines = append(lines, "", b.t.explanation.Bold(true).Render(e.explanation()), "")
+
+	for _, line := range hexLines(e, 8) { //nolint:mnd
+		lines = append(lines, b.t.sequence.Render(line))
+	}
+
+	lines = append(lines, "", b.t.sequence.Render("Screen"))
+	for _, line := range b.screenAt(b.visible[b.cursor]) {
+		lines = append(lines, b.t.text.Render(line))
+	}
+	return lines
+}
+
+// screenAt returns the lines of the screen after the event at i. The
+// screen is kept between calls, so moving down only applies the events in
+// between.
+func (b *browser) screenAt(i int) []string {
+	if b.screen == nil || b.applied > i+1 {
+		b.screen, b.applied = newGrid(b.screenWidth, b.screenHeight), 0
+	}
+	for ; b.applied <= i; b.applied++ {
+		b.screen.apply(b.events[b.applied])
+	}
+	return b.screen.lines()
+}
+
+// kindsBar returns the kinds that can be hidden, with their keys.
+func (b *browser) kindsBar() string {
+	parts := make([]string, 0, len(browseKinds))
+	for i, kind := range browseKinds {
+		s := fmt.Sprintf("%d %s", i+1, kind)
+		if b.hidden[kind] {
+			parts = append(parts, b.t.sequence.Faint(true).Strikethrough(true).Render(s))
+		} else {
+			parts = append(parts, b.t.kindStyle(kind).UnsetString().UnsetWidth().UnsetMarginRight().Render(s))
+		}
+	}
+	return ansi.Truncate(strings.Join(parts, "  "), b.width, "…")
+}
+
+// statusLine returns the search being typed, or the position and a
+// message.
+func (b *browser) statusLine() string {
+	if b.searching {
+		return ansi.Truncate("/"+b.query, b.width, "…")
+	}
+	s := fmt.Sprintf("%d/%d", min(b.cursor+1, len(b.visible)), len(b.visible))
+	if len(b.visible) < len(b.events) {
+		s += fmt.Sprintf(" of %d", len(b.events))
+	}
+	msg := b.msg
+	if msg == "" {
+		msg = browseHelp
+	}
+	return ansi.Truncate(b.t.sequence.Render(s+" · "+msg), b.width, "…")
+}
+
+// block returns the lines truncated and padded to fill width and height.
+func block(lines []string, width, height int) string {
+	out := make([]string, height)
+	for i := range out {
+		var line string
+		if i < len(lines) {
+			line = ansi.Truncate(lines[i], width, "…")
+		}
+		out[i] = line + strings.Repeat(" ", max(0, width-ansi.StringWidth(line)))
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,326 @@
+package sequin
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var errCast = errors.New("invalid cast")
+
+// Streams of a recording.
+const (
+	streamOutput = "o"
+	streamInput  = "i"
+	streamResize = "r"
+	streamMarker = "m"
+	streamExit   = "x"
+
+	// streamError is the stderr of an executed command, which casts don't
+	// have.
+	streamError = "e"
+)
+
+// chunk is a piece of the input: bytes written to or read from the terminal,
+// at some time since the start of a recording. Plain input is a single chunk
+// without a stream.
+type chunk struct {
+	stream string
+	at     time.Duration
+	data   []byte
+}
+
+// recording is a stream as it was recorded: its chunks, when it started,
+// and the size of the terminal, if known.
+type recording struct {
+	chunks        []chunk
+	start         time.Time
+	width, height int
+}
+
+// size returns the size of the terminal of the recording, or the default
+// one if it isn't known.
+func (r recording) size() (width, height int) {
+	if r.width <= 0 || r.height <= 0 {
+		return defaultWidth, defaultHeight
+	}
+	return r.width, r.height
+}
+
+// cast is an asciinema recording.
+type cast struct {
+	header        []byte
+	version       int
+	start         time.Time
+	width, height int
+	chunks        []chunk
+
+	// lines holds the lines after the header, comments included, and
+	// chunkOf the index of the chunk each was read into, or -1 for a
+	// comment.
+	lines   [][]byte
+	chunkOf []int
+}
+
+// readChunks returns the recording in the input, which is an asciinema
+// cast, a ttyrec recording, or plain output.
+func readChunks(in []byte) (recording, error) {
+	if chunks, start, ok := readTtyrec(in); ok {
+		return recording{chunks: chunks, start: start}, nil
+	}
+	if !isCast(in) {
+		return recording{chunks: []chunk{{data: in}}}, nil
+	}
+	c, err := readCast(in)
+	if err != nil {
+		return recording{}, err
+	}
+	return recording{chunks: c.chunks, start: c.start, width: c.width, height: c.height}, nil
+}
+
+// isCast reports whether the input starts with the header of an asciinema
+// cast, version 2 or 3.
+func isCast(in []byte) bool {
+	line, _, _ := bytes.Cut(in, []byte("\n"))
+	var header struct {
+		Version int `json:"version"`
+	}
+	if json.Unmarshal(line, &header) != nil {
+		return false
+	}
+	return header.Version == 2 || header.Version == 3 //nolint:mnd
+}
+
+// readCast reads an asciinema cast. Event times are absolute in version 2,
+// and relative to the previous event in version 3.
+func readCast(in []byte) (cast, error) {
+	lines := bytes.Split(in, []byte("\n"))
+	c := cast{header: lines[0]}
+	var header struct {
+		Version   int   `json:"version"`
+		Timestamp int64 `json:"timestamp"`
+
+		// The size is given as width and height in version 2, and as
+		// the cols and rows of the term in version 3.
+		Width  int `json:"width"`
+		Height int `json:"height"`
+		Term   struct {
+			Cols int `json:"cols"`
+			Rows int `json:"rows"`
+		} `json:"term"`
+	}
+	if err := json.Unmarshal(c.header, &header); err != nil {
+		return c, fmt.Errorf("%w: header: %w", errCast, err)
+	}
+	c.version = header.Version
+	c.width, c.height = header.Width, header.Height
+	if c.version == 3 { //nolint:mnd
+		c.width, c.height = header.Term.Cols, header.Term.Rows
+	}
+	if header.Timestamp > 0 {
+		c.start = time.Unix(header.Timestamp, 0)
+	}
+
+	var at time.Duration
+	for i, line := range lines[1:] {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '#' {
+			// Version 3 allows comments, which are kept to be written
+			// back.
+			c.lines, c.chunkOf = append(c.lines, line), append(c.chunkOf, -1)
+			continue
+		}
+		var ev []any
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return c, fmt.Errorf("%w: line %d: %w", errCast, i+2, err) //nolint:mnd
+		}
+		var t float64
+		var stream, data string
+		ok := len(ev) == 3 //nolint:mnd
+		if ok {
+			t, ok = ev[0].(float64)
+		}
+		if ok {
+			stream, ok = ev[1].(string)
+		}
+		if ok {
+			data, ok = ev[2].(string)
+		}
+		if !ok {
+			return c, fmt.Errorf("%w: line %d: expected [time, code, data]", errCast, i+2) //nolint:mnd
+		}
+		d := time.Duration(t * float64(time.Second))
+		if c.version == 3 { //nolint:mnd
+			at += d
+		} else {
+			at = d
+		}
+		c.chunks = append(c.chunks, chunk{stream: stream, at: at, data: []byte(data)})
+		c.lines, c.chunkOf = append(c.lines, line), append(c.chunkOf, len(c.chunks)-1)
+	}
+	return c, nil
+}
+
+// decodeChunks decodes the chunks like decode, and calls fn for each event in
+// order of time. Output, errors, and input are decoded separately, and events get the
+// time of the chunk they start in, and the gap since the previous event.
+func decodeChunks(chunks []chunk, fn func(event)) {
+	if len(chunks) == 1 && chunks[0].stream == "" {
+		decode(chunks[0].data, fn)
+		return
+	}
+
+	var events []event
+	for _, stream := range []string{streamOutput, streamError, streamInput} {
+		var in []byte
+		var starts []int
+		var times []time.Duration
+		for _, c := range chunks {
+			if c.stream == stream {
+				starts = append(starts, len(in))
+				times = append(times, c.at)
+				in = append(in, c.data...)
+			}
+		}
+		decode(in, func(e event) {
+			i := sort.SearchInts(starts, e.offset+1) - 1
+			e.stream, e.at = stream, times[i]
+			events = append(events, e)
+		})
+	}
+	for _, c := range chunks {
+		if e, ok := metaEvent(c); ok {
+			events = append(events, e)
+		}
+	}
+
+	slices.SortStableFunc(events, func(a, b event) int {
+		return cmp.Compare(a.at, b.at)
+	})
+	var last time.Duration
+	for _, e := range events {
+		e.gap, last = e.at-last, e.at
+		fn(e)
+	}
+}
+
+// metaEvent returns the event for a chunk that isn't terminal input or
+// output, like a resize.
+func metaEvent(c chunk) (event, bool) {
+	e := event{stream: c.stream, at: c.at, seq: c.data}
+	switch c.stream {
+	case streamResize:
+		e.kind, e.desc = "Resize", "Resize to "+string(c.data)
+	case streamMarker:
+		e.kind, e.desc = "Marker", "Marker "+strconv.Quote(string(c.data))
+	case streamExit:
+		e.kind, e.desc = "Exit", "Exit status "+string(c.data)
+	default:
+		return e, false
+	}
+	return e, true
+}
+
+func castCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cast [file]",
+		Short: "Add the explanations of sequences to an asciinema cast, as markers",
+		Args:  cobra.MaximumNArgs(1),
+		Example: `
+# Step through the explanations of a recording in the asciinema player:
+sequin cast demo.cast >annotated.cast
+asciinema play annotated.cast
+	`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, err := readInput(cmd, args)
+			if err != nil {
+				return err
+			}
+			if !isCast(in) {
+				return fmt.Errorf("%w: no asciinema v2 or v3 header", errCast)
+			}
+			c, err := readCast(in)
+			if err != nil {
+				return err
+			}
+			f, err := newFilter()
+			if err != nil {
+				return err
+			}
+			return writeAnnotatedCast(cmd.OutOrStdout(), c, f)
+		},
+	}
+}
+
+// writeAnnotatedCast writes the cast with a marker after each output chunk
+// for every sequence that starts in it.
+func writeAnnotatedCast(w io.Writer, c cast, f filter) error {
+	var in []byte
+	var starts, outputs []int
+	for i, ch := range c.chunks {
+		if ch.stream == streamOutput {
+			starts = append(starts, len(in))
+			outputs = append(outputs, i)
+			in = append(in, ch.data...)
+		}
+	}
+	markers := make([][]string, len(c.chunks))
+	decode(in, func(e event) {
+		if e.kind == "Text" || !f.keep(e) {
+			return
+		}
+		i := outputs[sort.SearchInts(starts, e.offset+1)-1]
+		markers[i] = append(markers[i], e.String())
+	})
+
+	if _, err := fmt.Fprintf(w, "%s\n", c.header); err != nil {
+		return err //nolint:wrapcheck
+	}
+	for j, line := range c.lines {
+		if _, err := fmt.Fprintf(w, "%s\n", line); err != nil {
+			return err //nolint:wrapcheck
+		}
+		i := c.chunkOf[j]
+		if i < 0 {
+			continue
+		}
+		for _, m := range markers[i] {
+			// Markers share the time of their chunk, which in version 3
+			// means an interval of zero.
+			t := c.chunks[i].at.Seconds()
+			if c.version == 3 { //nolint:mnd
+				t = 0
+			}
+			label, _ := json.Marshal(m)
+			if _, err := fmt.Fprintf(w, "[%.6f, %q, %s]\n", t, streamMarker, label); err != nil {
+				return err //nolint:wrapcheck
+			}
+		}
+	}
+	return nil
+}
+
+// streamNames are the short names of the streams of a recording.
+var streamNames = map[string]string{
+	streamOutput: "out",
+	streamError:  "err",
+	streamInput:  "in",
+}
+
+// onScreen reports whether the event was written to the terminal, on the
+// output or the errors of a recording.
+func (e event) onScreen() bool {
+	return e.stream == "" || e.stream == streamOutput || e.stream == streamError
+}
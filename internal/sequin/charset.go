@@ -0,0 +1,65 @@
+package sequin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+var charsetNames = map[byte]string{
+	charsetASCII:       "ASCII",
+	charsetUK:          "UK",
+	charsetLineDrawing: "DEC line drawing",
+}
+
+// lineDrawing maps ASCII characters to their DEC Special Graphics glyphs.
+var lineDrawing = map[rune]rune{
+	'`': '◆', 'a': '▒', 'b': '␉', 'c': '␌', 'd': '␍', 'e': '␊', 'f': '°',
+	'g': '±', 'h': '␤', 'i': '␋', 'j': '┘', 'k': '┐', 'l': '┌', 'm': '└',
+	'n': '┼', 'o': '⎺', 'p': '⎻', 'q': '─', 'r': '⎼', 's': '⎽', 't': '├',
+	'u': '┤', 'v': '┴', 'w': '┬', 'x': '│', 'y': '≤', 'z': '≥', '{': 'π',
+	'|': '≠', '}': '£', '~': '·',
+}
+
+//nolint:mnd
+func handleCharset(s *session, p *ansi.Parser) (string, error) {
+	cmd := ansi.Cmd(p.Command())
+	switch cmd.Intermediate() {
+	case 0:
+		// LS2/LS3 - Locking Shift 2/3
+		switch cmd.Final() {
+		case 'n':
+			s.gl = 2
+		case 'o':
+			s.gl = 3
+		default:
+			return "", errUnhandled
+		}
+		return fmt.Sprintf("Invoke G%d character set", s.gl), nil
+	case '(', ')', '*', '+':
+		// SCS - Select Character Set
+		g := int(cmd.Intermediate() - '(')
+		name, ok := charsetNames[cmd.Final()]
+		if !ok {
+			return "", errInvalid
+		}
+		s.charsets[g] = cmd.Final()
+		return fmt.Sprintf("Set G%d character set to %s", g, name), nil
+	}
+	return "", errUnhandled
+}
+
+// charsetText returns text as displayed with the character set currently
+// invoked into GL.
+func charsetText(s *session, text string) string {
+	if s.charset() != charsetLineDrawing {
+		return text
+	}
+	return strings.Map(func(r rune) rune {
+		if g, ok := lineDrawing[r]; ok {
+			return g
+		}
+		return r
+	}, text)
+}
@@ -1,4 +1,4 @@
-package main
+package sequin
 
 import (
 	"bytes"
@@ -14,7 +14,7 @@ var clipboardName = map[string]string{
 }
 
 //nolint:mnd
-func handleClipboard(p *ansi.Parser) (string, error) {
+func handleClipboard(_ *session, p *ansi.Parser) (string, error) {
 	parts := bytes.Split(p.Data(), []byte{';'})
 	if len(parts) != 3 {
 		// Invalid, ignore
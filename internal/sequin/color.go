@@ -1,4 +1,4 @@
-package main
+package sequin
 
 import (
 	"bytes"
@@ -7,7 +7,7 @@ import (
 )
 
 //nolint:mnd
-func handleTerminalColor(p *ansi.Parser) (string, error) {
+func handleTerminalColor(_ *session, p *ansi.Parser) (string, error) {
 	parts := bytes.Split(p.Data(), []byte{';'})
 	if len(parts) != 2 {
 		// Invalid, ignore
@@ -37,7 +37,7 @@ func handleTerminalColor(p *ansi.Parser) (string, error) {
 }
 
 //nolint:mnd
-func handleResetTerminalColor(p *ansi.Parser) (string, error) {
+func handleResetTerminalColor(_ *session, p *ansi.Parser) (string, error) {
 	parts := bytes.Split(p.Data(), []byte{';'})
 	if len(parts) != 1 {
 		// Invalid, ignore
@@ -1,13 +1,14 @@
-package main
+package sequin
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/charmbracelet/x/ansi"
 )
 
 //nolint:mnd
-func handleCursor(p *ansi.Parser) (string, error) {
+func handleCursor(s *session, p *ansi.Parser) (string, error) {
 	count := 1
 	if n, ok := p.Param(0, 1); ok && n > 0 {
 		count = n
@@ -50,8 +51,24 @@ func handleCursor(p *ansi.Parser) (string, error) {
 		}
 		return "Request cursor position", nil
 	case 's':
+		if s.modes[modeLeftRight] {
+			// DECSLRM - Set Left and Right Margins
+			left, right := 1, "last"
+			if n, ok := p.Param(0, 1); ok && n > 0 {
+				left = n
+			}
+			if n, _ := p.Param(1, 0); n > 0 {
+				right = strconv.Itoa(n)
+			}
+			return fmt.Sprintf("Set left and right margins to left=%d right=%s", left, right), nil
+		}
+		// SCOSC - Save Current Cursor Position
+		if len(p.Params()) > 0 {
+			return "Save cursor position (left/right margins need mode 69)", nil
+		}
 		return "Save cursor position", nil
 	case 'u':
+		// SCORC - Restore Current Cursor Position
 		return "Restore cursor position", nil
 	case 'q':
 		return fmt.Sprintf("Set cursor style %s", descCursorStyle(count)), nil
@@ -1,4 +1,4 @@
-package main
+package sequin
 
 import (
 	"bytes"
@@ -9,7 +9,7 @@ import (
 )
 
 //nolint:mnd
-func handleWorkingDirectoryURL(p *ansi.Parser) (string, error) {
+func handleWorkingDirectoryURL(_ *session, p *ansi.Parser) (string, error) {
 	parts := bytes.Split(p.Data(), []byte{';'})
 	if len(parts) != 2 {
 		// Invalid, ignore
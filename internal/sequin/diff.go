@@ -0,0 +1,325 @@
+package sequin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/colorprofile"
+	"github.com/spf13/cobra"
+)
+
+var screenOnly bool
+
+func diffCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "diff a b",
+		Short: "Show the differences between two streams",
+		Args:  cobra.ExactArgs(2), //nolint:mnd
+		Example: `
+# Explain why a golden file changed:
+git show HEAD:testdata/TestApp.golden >old.golden
+sequin diff old.golden testdata/TestApp.golden
+
+# Only show differences in what is displayed:
+sequin diff --screen old.golden testdata/TestApp.golden
+	`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			a, err := os.ReadFile(args[0])
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+			b, err := os.ReadFile(args[1])
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+			w := colorprofile.NewWriter(cmd.OutOrStdout(), os.Environ())
+			var n int
+			if screenOnly {
+				ra, err := readChunks(a)
+				if err != nil {
+					return err
+				}
+				rb, err := readChunks(b)
+				if err != nil {
+					return err
+				}
+				n = diffScreens(w, newTheme(), ra, rb)
+			} else {
+				n = diffStreams(w, newTheme(), a, b)
+			}
+			if n > 0 {
+				return fmt.Errorf("%w: %d differences", errDiffer, n)
+			}
+			return nil
+		},
+	}
+	c.Flags().BoolVar(&screenOnly, "screen", false, "only compare the rendered screens, ignoring differences that don't change them")
+	return c
+}
+
+// diffStreams writes the events added, removed, and changed between a and
+// b to w, with their offsets in a or b, and returns the number of
+// differences.
+func diffStreams(w io.Writer, t theme, a, b []byte) int {
+	var ea, eb []event
+	decode(a, func(e event) { ea = append(ea, e) })
+	decode(b, func(e event) { eb = append(eb, e) })
+
+	var removed, added, changed int
+	var dels, adds []event
+	flush := func() {
+		// Removed events are paired in order with added events doing the
+		// same thing, a few events apart at most.
+		var j int
+		for _, d := range dels {
+			k := j
+			for k < len(adds) && k < j+maxPairing && label(adds[k]) != label(d) {
+				k++
+			}
+			if k == len(adds) || k == j+maxPairing {
+				removed++
+				_, _ = fmt.Fprintln(w, t.error.Render(fmt.Sprintf("- a:%08x %s", d.offset, diffLine(d))))
+				continue
+			}
+			for ; j < k; j++ {
+				added++
+				_, _ = fmt.Fprintln(w, t.explanation.Render(fmt.Sprintf("+ b:%08x %s", adds[j].offset, diffLine(adds[j]))))
+			}
+			changed++
+			from, to := diffDesc(d), diffDesc(adds[k])
+			if from == to {
+				// Same effect, different bytes.
+				from, to = quote(d.seq), quote(adds[k].seq)
+			}
+			_, _ = fmt.Fprintln(w, t.sequence.Render(fmt.Sprintf("~ a:%08x b:%08x ", d.offset, adds[k].offset))+
+				t.explanation.Render(fmt.Sprintf("%s changed: %s → %s", label(d), from, to)))
+			j = k + 1
+		}
+		for ; j < len(adds); j++ {
+			added++
+			_, _ = fmt.Fprintln(w, t.explanation.Render(fmt.Sprintf("+ b:%08x %s", adds[j].offset, diffLine(adds[j]))))
+		}
+		dels, adds = nil, nil
+	}
+
+	ops, ok := align(ea, eb)
+	if !ok {
+		_, _ = fmt.Fprintln(w, t.error.Bold(true).Render(fmt.Sprintf(
+			"more than %d events differ: those between the common start and end are paired in order", maxEdits,
+		)))
+	}
+	for _, op := range ops {
+		switch {
+		case op.a != nil && op.b != nil:
+			flush()
+		case op.a != nil:
+			dels = append(dels, *op.a)
+		default:
+			adds = append(adds, *op.b)
+		}
+	}
+	flush()
+
+	n := removed + added + changed
+	if n > 0 {
+		_, _ = fmt.Fprintln(w, t.explanation.Bold(true).Render(fmt.Sprintf(
+			"%d removed, %d added, %d changed", removed, added, changed,
+		)))
+	}
+	return n
+}
+
+// diffScreens writes the rows that differ between the screens rendered by a
+// and b to w, and returns the number of such rows.
+func diffScreens(w io.Writer, t theme, a, b recording) int {
+	la, lb := render(a).lines(), render(b).lines()
+
+	var n int
+	for y := range max(len(la), len(lb)) {
+		if lineAt(la, y) == lineAt(lb, y) {
+			continue
+		}
+		n++
+		_, _ = fmt.Fprintln(w, t.error.Render(strings.TrimRight(fmt.Sprintf("%5d - %s", y+1, lineAt(la, y)), " ")))
+		_, _ = fmt.Fprintln(w, t.explanation.Render(strings.TrimRight(fmt.Sprintf("%5d + %s", y+1, lineAt(lb, y)), " ")))
+	}
+	return n
+}
+
+// render returns the screen the recording leaves, at its size and with its
+// resizes.
+func render(r recording) *grid {
+	g := newGrid(r.size())
+	for _, c := range r.chunks {
+		g.apply(event{stream: c.stream, seq: c.data})
+	}
+	return g
+}
+
+// label names what an event does, so that events doing the same thing
+// differently are reported as changed.
+func label(e event) string {
+	if e.name != "" {
+		return e.name
+	}
+	if e.kind == "" {
+		return unknown
+	}
+	return e.kind
+}
+
+// diffDesc describes the event for a change.
+func diffDesc(e event) string {
+	if e.kind == "Text" {
+		return fmt.Sprintf("%q", e.desc)
+	}
+	if e.explanation() == "" {
+		return seqString(e.seq)
+	}
+	return e.explanation()
+}
+
+// diffLine describes the event on a single line, for an addition or removal,
+// and for textconv.
+func diffLine(e event) string {
+	if e.kind == "Text" {
+		return fmt.Sprintf("Text %q", e.desc)
+	}
+	return e.String()
+}
+
+// alignment pairs an event of one stream with an event of the other. One of
+// them is nil for events only in one stream.
+type alignment struct {
+	a, b *event
+}
+
+// maxPairing is how many added events apart a removed event can be paired
+// with one doing the same thing, to report a change.
+const maxPairing = 100
+
+// maxEdits is how many events align removes and adds at most to match up two
+// streams. Past that, the events in between are all removed and added.
+const maxEdits = 2000
+
+// align returns a shortest edit script between the events, as a list of
+// matches, removals, and additions. Events match if their bytes are equal.
+// It reports false if the streams differ by more than maxEdits events: the
+// events between their common start and end are then all removed and added.
+func align(a, b []event) ([]alignment, bool) {
+	eq := func(i, j int) bool { return bytes.Equal(a[i].seq, b[j].seq) }
+
+	// Common prefixes and suffixes are the usual case, and keep the search
+	// below short.
+	var pre int
+	for pre < len(a) && pre < len(b) && eq(pre, pre) {
+		pre++
+	}
+	var suf int
+	for suf < len(a)-pre && suf < len(b)-pre && eq(len(a)-1-suf, len(b)-1-suf) {
+		suf++
+	}
+
+	var out []alignment
+	for i := range pre {
+		out = append(out, alignment{&a[i], &b[i]})
+	}
+	n, m := len(a)-pre-suf, len(b)-pre-suf
+	edits, ok := myers(n, m, func(i, j int) bool { return eq(pre+i, pre+j) })
+	if !ok {
+		for i := range n {
+			out = append(out, alignment{a: &a[pre+i]})
+		}
+		for j := range m {
+			out = append(out, alignment{b: &b[pre+j]})
+		}
+	}
+	for _, e := range edits {
+		var op alignment
+		if e.i >= 0 {
+			op.a = &a[pre+e.i]
+		}
+		if e.j >= 0 {
+			op.b = &b[pre+e.j]
+		}
+		out = append(out, op)
+	}
+	for k := range suf {
+		out = append(out, alignment{&a[len(a)-suf+k], &b[len(b)-suf+k]})
+	}
+	return out, ok
+}
+
+// edit is a step of an edit script: i and j index the elements of the two
+// sequences matched, or one of them is -1 for an element of the other only.
+type edit struct {
+	i, j int
+}
+
+// myers returns a shortest edit script between sequences of n and m elements,
+// with Myers' O(ND) algorithm. It gives up with false past maxEdits edits.
+func myers(n, m int, eq func(i, j int) bool) ([]edit, bool) {
+	limit := min(n+m, maxEdits)
+	// v holds the furthest x reached on each diagonal k = x-y, offset by
+	// limit+1. trace holds v before each step, from diagonal -d-1 to d+1.
+	off := limit + 1
+	v := make([]int, 2*limit+3) //nolint:mnd
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, slices.Clone(v[off-d-1:off+d+2]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[off+k-1] < v[off+k+1] {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && eq(x, y) {
+				x, y = x+1, y+1
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrack follows the steps recorded by myers back from the end, and
+// returns them in order.
+func backtrack(trace [][]int, x, y int) []edit {
+	var out []edit
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prev := k - 1
+		if k == -d || k != d && v[d+k] < v[d+k+2] {
+			prev = k + 1
+		}
+		px := v[d+1+prev]
+		py := px - prev
+		for x > px && y > py {
+			x, y = x-1, y-1
+			out = append(out, edit{x, y})
+		}
+		if x == px {
+			y--
+			out = append(out, edit{-1, y})
+		} else {
+			x--
+			out = append(out, edit{x, -1})
+		}
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		out = append(out, edit{x, y})
+	}
+	slices.Reverse(out)
+	return out
+}
@@ -0,0 +1,214 @@
+package sequin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+)
+
+var errNoDoc = errors.New("no documentation")
+
+// entry is a registered sequence.
+type entry struct {
+	kind string
+	cmd  int
+	name string
+	doc  *doc
+}
+
+// entries returns the sequences sequin knows, by kind and command.
+func entries() []entry {
+	var all []entry
+	for _, r := range []struct {
+		kind string
+		reg  registry
+	}{
+		{"CSI", csiHandlers},
+		{"CSI", alternates(csiAlternates)},
+		{"ESC", escHandler},
+		{"DCS", dcsHandlers},
+		{"OSC", oscHandlers},
+	} {
+		cmds := make([]int, 0, len(r.reg))
+		for cmd := range r.reg {
+			cmds = append(cmds, cmd)
+		}
+		slices.Sort(cmds)
+		for _, cmd := range cmds {
+			h := r.reg[cmd]
+			all = append(all, entry{r.kind, cmd, h.name, h.doc})
+		}
+	}
+	return all
+}
+
+// form returns the bytes of the sequence as a template, like "CSI ? Pm h".
+func (e entry) form() string {
+	var params []string
+	for _, p := range e.doc.params {
+		if strings.HasSuffix(p.name, "...") {
+			params = append(params, "Pm")
+		} else {
+			params = append(params, "Ps")
+		}
+	}
+
+	if e.kind == "OSC" {
+		s := fmt.Sprintf("OSC %d", e.cmd)
+		if len(params) > 0 {
+			s += " ; Pt"
+		}
+		return s + " ST"
+	}
+
+	parts := []string{e.kind}
+	cmd := ansi.Cmd(e.cmd)
+	if p := cmd.Prefix(); p != 0 {
+		parts = append(parts, string(p))
+	}
+	if e.kind == "CSI" && len(params) > 0 {
+		parts = append(parts, strings.Join(params, " ; "))
+	}
+	switch i := cmd.Intermediate(); i {
+	case 0:
+	case ' ':
+		parts = append(parts, "SP")
+	default:
+		parts = append(parts, string(i))
+	}
+	parts = append(parts, string(cmd.Final()))
+	if e.kind == "DCS" {
+		parts = append(parts, "Pt", "ST")
+	}
+	return strings.Join(parts, " ")
+}
+
+func listCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the sequences sequin knows",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			w := colorprofile.NewWriter(cmd.OutOrStdout(), os.Environ())
+			t := newTheme()
+			var nameWidth, formWidth int
+			for _, e := range entries() {
+				nameWidth = max(nameWidth, len(e.name))
+				formWidth = max(formWidth, len(e.form()))
+			}
+			for _, e := range entries() {
+				_, _ = fmt.Fprintf(
+					w,
+					"%s  %s  %s\n",
+					t.explanation.Render(fmt.Sprintf("%-*s", nameWidth, e.name)),
+					t.sequence.Render(fmt.Sprintf("%-*s", formWidth, e.form())),
+					t.explanation.Render(e.doc.desc),
+				)
+			}
+			return nil
+		},
+	}
+}
+
+func docCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doc mnemonic|sequence",
+		Short: "Show the documentation of a sequence",
+		Args:  cobra.ExactArgs(1),
+		Example: `
+# Look up a sequence by mnemonic:
+sequin doc CUP
+
+# Or by its bytes, with spaces between them if you like:
+sequin doc 'CSI ? 1049 h'
+	`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := colorprofile.NewWriter(cmd.OutOrStdout(), os.Environ())
+			return printDoc(w, newTheme(), args[0])
+		},
+	}
+}
+
+// printDoc writes the documentation of the sequence named by query, which is
+// either a mnemonic, or a sequence written as with encode, like 'CSI ?1049h'.
+func printDoc(w io.Writer, t theme, query string) error {
+	name := strings.ToUpper(query)
+	var example *event
+	if findDoc(name) == nil {
+		// Not a mnemonic: decode the sequence and document its handler.
+		words := strings.Fields(query)
+		if len(words) == 0 {
+			return fmt.Errorf("%w for %q", errNoDoc, query)
+		}
+		switch strings.ToUpper(words[0]) {
+		case "CSI", "ESC", "OSC", "DCS":
+			// Spaces only separate the bytes for readability.
+			query = words[0] + " " + strconv.Quote(strings.Join(words[1:], ""))
+		}
+		src, err := assemble(query)
+		if err != nil {
+			return fmt.Errorf("%w for %q", errNoDoc, query)
+		}
+		decode([]byte(src), func(e event) {
+			if example == nil && e.name != "" {
+				example = &e
+			}
+		})
+		if example == nil {
+			return fmt.Errorf("%w for %q", errNoDoc, query)
+		}
+		name = example.name
+	}
+
+	d := findDoc(name)
+	field := func(label, value string) {
+		if value == "" {
+			return
+		}
+		_, _ = fmt.Fprintln(w, t.sequence.Render(fmt.Sprintf("  %-12s", label))+t.explanation.Render(value))
+	}
+
+	_, _ = fmt.Fprintln(w, t.explanation.Bold(true).Render(name+": "+d.desc))
+	for _, e := range entries() {
+		if e.name == name {
+			field("Sequence", e.form())
+		}
+	}
+	for i, p := range d.params {
+		label := ""
+		if i == 0 {
+			label = "Parameters"
+		}
+		value := p.name
+		if p.def != "" {
+			value += " (default " + p.def + ")"
+		}
+		field(label, value)
+	}
+	field("Reply", d.reply)
+	field("Origin", d.origin)
+	field("Reference", d.ref)
+	if example != nil {
+		field("Example", example.String())
+	}
+	return nil
+}
+
+// findDoc returns the doc of the handler with the given mnemonic, or nil if
+// there is none.
+func findDoc(name string) *doc {
+	for _, e := range entries() {
+		if e.name == name {
+			return e.doc
+		}
+	}
+	return nil
+}
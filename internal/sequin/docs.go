@@ -0,0 +1,447 @@
+package sequin
+
+// doc describes a sequence for sequin list and sequin doc.
+type doc struct {
+	desc   string
+	params []param
+
+	// reply is the sequence the terminal answers with, if any.
+	reply string
+
+	// origin is the standard or terminal that introduced the sequence, and
+	// ref a link to its specification.
+	origin string
+	ref    string
+}
+
+// param is a parameter of a sequence. A name ending in "..." stands for a
+// list of parameters.
+type param struct {
+	name string
+	def  string
+}
+
+const (
+	originECMA48 = "ECMA-48"
+	originDEC    = "DEC"
+	originXterm  = "xterm"
+	originKitty  = "kitty"
+	originITerm2 = "iTerm2"
+	originSCO    = "SCO"
+
+	refCtlseqs = "https://invisible-island.net/xterm/ctlseqs/ctlseqs.html"
+	refVT510   = "https://vt100.net/docs/vt510-rm/"
+	refKitty   = "https://sw.kovidgoyal.net/kitty/keyboard-protocol/"
+	refITerm2  = "https://iterm2.com/documentation-escape-codes.html"
+)
+
+// The docs of the sequences, which their handlers refer to.
+var (
+	// CSI
+	docSGR = &doc{
+		desc:   "Select graphic rendition: text attributes and colors",
+		params: []param{{"attributes...", "0"}},
+		origin: originECMA48, ref: refVT510 + "SGR.html",
+	}
+	docDA1 = &doc{
+		desc:   "Request primary device attributes",
+		params: []param{{"request", "0"}},
+		reply:  "CSI ? Ps ; ... c",
+		origin: originDEC, ref: refVT510 + "DA1.html",
+	}
+	docKITTYQUERY = &doc{
+		desc:   "Query the progressive enhancement flags of the keyboard protocol",
+		reply:  "CSI ? flags u",
+		origin: originKitty, ref: refKitty,
+	}
+	docKITTYKEY = &doc{
+		desc:   "Report a key, once the keyboard protocol is on",
+		params: []param{{"code", ""}, {"modifiers", "1"}, {"text...", ""}},
+		origin: originKitty, ref: refKitty,
+	}
+	docKITTYPUSH = &doc{
+		desc:   "Push keyboard protocol flags onto the stack",
+		params: []param{{"flags", "0"}},
+		origin: originKitty, ref: refKitty,
+	}
+	docKITTYPOP = &doc{
+		desc:   "Pop keyboard protocol flags from the stack",
+		params: []param{{"count", "1"}},
+		origin: originKitty, ref: refKitty,
+	}
+	docKITTYSET = &doc{
+		desc:   "Set keyboard protocol flags",
+		params: []param{{"flags", "0"}, {"mode", "1"}},
+		origin: originKitty, ref: refKitty,
+	}
+	docCUU = &doc{
+		desc:   "Cursor up",
+		params: []param{{"count", "1"}},
+		origin: originECMA48, ref: refVT510 + "CUU.html",
+	}
+	docCUD = &doc{
+		desc:   "Cursor down",
+		params: []param{{"count", "1"}},
+		origin: originECMA48, ref: refVT510 + "CUD.html",
+	}
+	docCUF = &doc{
+		desc:   "Cursor forward",
+		params: []param{{"count", "1"}},
+		origin: originECMA48, ref: refVT510 + "CUF.html",
+	}
+	docCUB = &doc{
+		desc:   "Cursor backward",
+		params: []param{{"count", "1"}},
+		origin: originECMA48, ref: refVT510 + "CUB.html",
+	}
+	docCNL = &doc{
+		desc:   "Cursor to the start of a following line",
+		params: []param{{"count", "1"}},
+		origin: originECMA48, ref: refVT510 + "CNL.html",
+	}
+	docCPL = &doc{
+		desc:   "Cursor to the start of a preceding line",
+		params: []param{{"count", "1"}},
+		origin: originECMA48, ref: refVT510 + "CPL.html",
+	}
+	docCUP = &doc{
+		desc:   "Cursor position",
+		params: []param{{"row", "1"}, {"column", "1"}},
+		origin: originECMA48, ref: refVT510 + "CUP.html",
+	}
+	docDECDSR = &doc{
+		desc:   "Device status report, DEC format",
+		params: []param{{"report", ""}},
+		reply:  "CSI ? row ; column ; page R, for report 6",
+		origin: originDEC, ref: refVT510 + "DSR.html",
+	}
+	docDSR = &doc{
+		desc:   "Device status report",
+		params: []param{{"report", ""}},
+		reply:  "CSI 0 n for report 5, CSI row ; column R for report 6",
+		origin: originECMA48, ref: refVT510 + "DSR.html",
+	}
+	docSCOSC = &doc{
+		desc:   "Save cursor position, or set left and right margins (DECSLRM) with mode 69",
+		origin: originSCO, ref: refCtlseqs,
+	}
+	docSCORC = &doc{
+		desc:   "Restore cursor position",
+		origin: originSCO, ref: refCtlseqs,
+	}
+	docDECSCUSR = &doc{
+		desc:   "Set cursor style",
+		params: []param{{"style", "1"}},
+		origin: originDEC, ref: refVT510 + "DECSCUSR.html",
+	}
+	docDECSTBM = &doc{
+		desc:   "Set top and bottom margins (scrolling region)",
+		params: []param{{"top", "1"}, {"bottom", "last line"}},
+		origin: originDEC, ref: refVT510 + "DECSTBM.html",
+	}
+	docED = &doc{
+		desc:   "Erase in display",
+		params: []param{{"mode", "0"}},
+		origin: originECMA48, ref: refVT510 + "ED.html",
+	}
+	docEL = &doc{
+		desc:   "Erase in line",
+		params: []param{{"mode", "0"}},
+		origin: originECMA48, ref: refVT510 + "EL.html",
+	}
+	docIL = &doc{
+		desc:   "Insert lines",
+		params: []param{{"count", "1"}},
+		origin: originECMA48, ref: refVT510 + "IL.html",
+	}
+	docDL = &doc{
+		desc:   "Delete lines",
+		params: []param{{"count", "1"}},
+		origin: originECMA48, ref: refVT510 + "DL.html",
+	}
+	docSU = &doc{
+		desc:   "Scroll up",
+		params: []param{{"count", "1"}},
+		origin: originECMA48, ref: refVT510 + "SU.html",
+	}
+	docSD = &doc{
+		desc:   "Scroll down",
+		params: []param{{"count", "1"}},
+		origin: originECMA48, ref: refVT510 + "SD.html",
+	}
+	docDECSED = &doc{
+		desc:   "Selective erase in display, sparing protected characters",
+		params: []param{{"mode", "0"}},
+		origin: originDEC, ref: refVT510 + "DECSED.html",
+	}
+	docDECSEL = &doc{
+		desc:   "Selective erase in line, sparing protected characters",
+		params: []param{{"mode", "0"}},
+		origin: originDEC, ref: refVT510 + "DECSEL.html",
+	}
+	docDECCRA = &doc{
+		desc: "Copy rectangular area",
+		params: []param{
+			{"top", "1"}, {"left", "1"}, {"bottom", "last line"}, {"right", "last column"},
+			{"page", "1"}, {"destination top", "1"}, {"destination left", "1"}, {"destination page", "1"},
+		},
+		origin: originDEC, ref: refVT510 + "DECCRA.html",
+	}
+	docDECFRA = &doc{
+		desc: "Fill rectangular area with a character",
+		params: []param{
+			{"character", ""}, {"top", "1"}, {"left", "1"}, {"bottom", "last line"}, {"right", "last column"},
+		},
+		origin: originDEC, ref: refVT510 + "DECFRA.html",
+	}
+	docDECERA = &doc{
+		desc:   "Erase rectangular area",
+		params: []param{{"top", "1"}, {"left", "1"}, {"bottom", "last line"}, {"right", "last column"}},
+		origin: originDEC, ref: refVT510 + "DECERA.html",
+	}
+	docDECSERA = &doc{
+		desc:   "Selective erase rectangular area, sparing protected characters",
+		params: []param{{"top", "1"}, {"left", "1"}, {"bottom", "last line"}, {"right", "last column"}},
+		origin: originDEC, ref: refVT510 + "DECSERA.html",
+	}
+	docDECCARA = &doc{
+		desc: "Change attributes in rectangular area",
+		params: []param{
+			{"top", "1"}, {"left", "1"}, {"bottom", "last line"}, {"right", "last column"}, {"attributes...", "0"},
+		},
+		origin: originDEC, ref: refVT510 + "DECCARA.html",
+	}
+	docDECRARA = &doc{
+		desc: "Reverse attributes in rectangular area",
+		params: []param{
+			{"top", "1"}, {"left", "1"}, {"bottom", "last line"}, {"right", "last column"}, {"attributes...", "0"},
+		},
+		origin: originDEC, ref: refVT510 + "DECRARA.html",
+	}
+	docDECSACE = &doc{
+		desc:   "Select attribute change extent: stream or rectangle",
+		params: []param{{"extent", "1"}},
+		origin: originDEC, ref: refVT510 + "DECSACE.html",
+	}
+	docDECRQCRA = &doc{
+		desc: "Request checksum of rectangular area",
+		params: []param{
+			{"id", ""}, {"page", "1"}, {"top", "1"}, {"left", "1"}, {"bottom", "last line"}, {"right", "last column"},
+		},
+		reply:  "DCS id ! ~ checksum ST",
+		origin: originDEC, ref: refVT510 + "DECRQCRA.html",
+	}
+	docDECSCA = &doc{
+		desc:   "Select character protection attribute",
+		params: []param{{"protection", "0"}},
+		origin: originDEC, ref: refVT510 + "DECSCA.html",
+	}
+	docDECRQM = &doc{
+		desc:   "Request mode",
+		params: []param{{"mode", ""}},
+		reply:  "CSI [?] mode ; setting $ y",
+		origin: originDEC, ref: refVT510 + "DECRQM.html",
+	}
+	docDECSET = &doc{
+		desc:   "Set private modes",
+		params: []param{{"modes...", ""}},
+		origin: originDEC, ref: refCtlseqs,
+	}
+	docDECRST = &doc{
+		desc:   "Reset private modes",
+		params: []param{{"modes...", ""}},
+		origin: originDEC, ref: refCtlseqs,
+	}
+	docSM = &doc{
+		desc:   "Set modes",
+		params: []param{{"modes...", ""}},
+		origin: originECMA48, ref: refVT510 + "SM.html",
+	}
+	docRM = &doc{
+		desc:   "Reset modes",
+		params: []param{{"modes...", ""}},
+		origin: originECMA48, ref: refVT510 + "RM.html",
+	}
+	docDECSTR = &doc{
+		desc:   "Soft terminal reset",
+		origin: originDEC, ref: refVT510 + "DECSTR.html",
+	}
+	docDECSCL = &doc{
+		desc:   "Select conformance level",
+		params: []param{{"level", "65"}, {"c1 controls", "0"}},
+		origin: originDEC, ref: refVT510 + "DECSCL.html",
+	}
+	docXTVERSION = &doc{
+		desc:   "Request terminal name and version",
+		params: []param{{"request", "0"}},
+		reply:  "DCS > | name and version ST",
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docXTMODKEYS = &doc{
+		desc:   "Set or reset key modifier options, like modifyOtherKeys",
+		params: []param{{"resource", ""}, {"value", ""}},
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docXTQMODKEYS = &doc{
+		desc:   "Query key modifier options",
+		params: []param{{"resource", ""}},
+		reply:  "CSI > resource ; value m",
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docXTPUSHSGR = &doc{
+		desc:   "Push video attributes onto the stack",
+		params: []param{{"attributes...", "all"}},
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docXTPOPSGR = &doc{
+		desc:   "Pop video attributes from the stack",
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docXTREPORTSGR = &doc{
+		desc:   "Report the video attributes of a rectangular area",
+		params: []param{{"top", ""}, {"left", ""}, {"bottom", ""}, {"right", ""}},
+		reply:  "CSI attributes... m",
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docXTPUSHCOLORS = &doc{
+		desc:   "Push the color palette onto the stack",
+		params: []param{{"index", ""}},
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docXTPOPCOLORS = &doc{
+		desc:   "Pop the color palette from the stack",
+		params: []param{{"index", ""}},
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docXTREPORTCOLORS = &doc{
+		desc:   "Report the current entry of the color palette stack",
+		reply:  "CSI ? top ; count # Q",
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docXTSMGRAPHICS = &doc{
+		desc:   "Set or request graphics attributes, like the number of sixel colors",
+		params: []param{{"item", ""}, {"action", ""}, {"values...", ""}},
+		reply:  "CSI ? item ; status ; values... S",
+		origin: originXterm, ref: refCtlseqs,
+	}
+
+	// OSC
+	docOSC0 = &doc{
+		desc:   "Set icon name and window title",
+		params: []param{{"title", ""}},
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docOSC1 = &doc{
+		desc:   "Set icon name",
+		params: []param{{"name", ""}},
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docOSC2 = &doc{
+		desc:   "Set window title",
+		params: []param{{"title", ""}},
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docOSC7 = &doc{
+		desc:   "Set the working directory, as a file:// URL",
+		params: []param{{"url", ""}},
+		origin: "Terminal.app", ref: refITerm2,
+	}
+	docOSC8 = &doc{
+		desc:   "Set or end a hyperlink",
+		params: []param{{"params", ""}, {"url", ""}},
+		origin: "GNOME Terminal", ref: "https://gist.github.com/egmontkob/eb114294efbcd5adb1944c9f3cb5feda",
+	}
+	docOSC9 = &doc{
+		desc:   "Post a notification",
+		params: []param{{"message", ""}},
+		origin: originITerm2, ref: refITerm2,
+	}
+	docOSC10 = &doc{
+		desc:   "Set or request the default foreground color",
+		params: []param{{"color or ?", ""}},
+		reply:  "OSC 10 ; rgb:rrrr/gggg/bbbb ST, for ?",
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docOSC11 = &doc{
+		desc:   "Set or request the default background color",
+		params: []param{{"color or ?", ""}},
+		reply:  "OSC 11 ; rgb:rrrr/gggg/bbbb ST, for ?",
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docOSC12 = &doc{
+		desc:   "Set or request the cursor color",
+		params: []param{{"color or ?", ""}},
+		reply:  "OSC 12 ; rgb:rrrr/gggg/bbbb ST, for ?",
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docOSC22 = &doc{
+		desc:   "Set the mouse pointer shape",
+		params: []param{{"shape", ""}},
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docOSC52 = &doc{
+		desc:   "Set or request the clipboard, with base64 data",
+		params: []param{{"clipboard", "s 0"}, {"data or ?", ""}},
+		reply:  "OSC 52 ; clipboard ; data ST, for ?",
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docOSC110 = &doc{
+		desc:   "Reset the default foreground color",
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docOSC111 = &doc{
+		desc:   "Reset the default background color",
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docOSC112 = &doc{
+		desc:   "Reset the cursor color",
+		origin: originXterm, ref: refCtlseqs,
+	}
+	docOSC133 = &doc{
+		desc:   "Mark shell prompts, commands, and their output",
+		params: []param{{"mark", ""}, {"options...", ""}},
+		origin: "FinalTerm", ref: "https://gitlab.freedesktop.org/Per_Bothner/specifications/blob/master/proposals/semantic-prompts.md",
+	}
+
+	// DCS
+	docXTGETTCAP = &doc{
+		desc:   "Request termcap or terminfo capabilities, hex encoded",
+		params: []param{{"names...", ""}},
+		reply:  "DCS 1 + r name = value ST, or DCS 0 + r ST if unknown",
+		origin: originXterm, ref: refCtlseqs,
+	}
+
+	// ESC
+	docDECSC = &doc{
+		desc:   "Save cursor, with its attributes and character sets",
+		origin: originDEC, ref: refVT510 + "DECSC.html",
+	}
+	docDECRC = &doc{
+		desc:   "Restore cursor saved with DECSC",
+		origin: originDEC, ref: refVT510 + "DECRC.html",
+	}
+	docDECKPNM = &doc{
+		desc:   "Keypad sends numbers",
+		origin: originDEC, ref: refVT510 + "DECKPNM.html",
+	}
+	docDECKPAM = &doc{
+		desc:   "Keypad sends application sequences",
+		origin: originDEC, ref: refVT510 + "DECKPAM.html",
+	}
+	docLS2 = &doc{
+		desc:   "Invoke the G2 character set into GL",
+		origin: originECMA48, ref: refVT510 + "LS2.html",
+	}
+	docLS3 = &doc{
+		desc:   "Invoke the G3 character set into GL",
+		origin: originECMA48, ref: refVT510 + "LS3.html",
+	}
+	docSCS = &doc{
+		desc:   "Designate a character set to G0, G1, G2, or G3",
+		origin: originDEC, ref: refVT510 + "SCS.html",
+	}
+	docST = &doc{
+		desc:   "String terminator",
+		origin: originECMA48, ref: "https://ecma-international.org/publications-and-standards/standards/ecma-48/",
+	}
+)
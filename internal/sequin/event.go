@@ -0,0 +1,258 @@
+package sequin
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// event is a single decoded piece of the input: a sequence, a control code,
+// or a run of text.
+type event struct {
+	// kind is one of CSI, DCS, OSC, APC, PM, SOS, ESC, Ctrl, or Text. It is
+	// empty for sequences that could not be decoded.
+	kind string
+
+	// seq holds the raw bytes of the event, and offset its position in the
+	// input.
+	seq    []byte
+	offset int
+
+	// name is the mnemonic of the handler that explained the sequence, if
+	// any, and cmd and params the command and parameters of the sequence.
+	name   string
+	cmd    ansi.Cmd
+	params ansi.Params
+
+	// desc is the explanation of the event, or err the reason it could not
+	// be explained. For text, desc is the text as displayed with the active
+	// character set, and charset names it if it isn't ASCII.
+	desc    string
+	err     error
+	charset string
+
+	// stream is the stream of a recording the event was read from, at the
+	// time it was recorded, and gap the time since the previous event. They
+	// are empty for plain input.
+	stream string
+	at     time.Duration
+	gap    time.Duration
+}
+
+// problem reports whether the event is an unknown, unrecognized, or invalid
+// sequence.
+func (e event) problem() bool {
+	return e.kind == "" || e.err != nil
+}
+
+// decode splits in into events, explains them with the registered handlers,
+// and calls fn for each of them in order. Consecutive printable characters
+// are reported as a single text event.
+func decode(in []byte, fn func(event)) {
+	newSession().decode(in, fn)
+}
+
+// decode is decode in the session, which keeps the state the sequences set
+// from one call to the next.
+func (sess *session) decode(in []byte, fn func(event)) {
+	var state byte
+	p := ansi.GetParser()
+	defer ansi.PutParser(p)
+
+	var offset int
+	text := -1 // offset of the pending text, if any
+	flush := func() {
+		if text < 0 {
+			return
+		}
+		seq := in[text:offset]
+		var charset string
+		if cs := sess.charset(); cs != charsetASCII {
+			charset = charsetNames[cs]
+		}
+		fn(event{
+			kind:    "Text",
+			seq:     seq,
+			offset:  text,
+			desc:    charsetText(sess, string(seq)),
+			charset: charset,
+		})
+		text = -1
+	}
+
+	emit := func(e event) {
+		e.offset = offset
+		fn(e)
+	}
+
+	explained := func(kind string, seq []byte, reg registry, alts map[int]alternate, variants bool) event {
+		e := event{kind: kind, seq: seq, cmd: ansi.Cmd(p.Command()), params: slices.Clone(p.Params())}
+		var h handler
+		h, e.desc, e.err = explain(reg, alts, sess, p, variants)
+		e.name = h.name
+		return e
+	}
+
+	for offset < len(in) {
+		seq, width, n, newState := ansi.DecodeSequence(in[offset:], state, p)
+		if width == 0 {
+			// Report pending text before the session is updated.
+			flush()
+		}
+
+		switch {
+		case ansi.HasCsiPrefix(seq):
+			emit(explained("CSI", seq, csiHandlers, csiAlternates, true))
+
+		case ansi.HasDcsPrefix(seq):
+			emit(explained("DCS", seq, dcsHandlers, nil, true))
+
+		case ansi.HasOscPrefix(seq):
+			emit(explained("OSC", seq, oscHandlers, nil, false))
+
+		case ansi.HasPmPrefix(seq):
+			emit(event{kind: "PM", seq: seq, desc: fmt.Sprintf("Privacy message %q", seqString(seq))})
+
+		case ansi.HasSosPrefix(seq):
+			emit(event{kind: "SOS", seq: seq, desc: fmt.Sprintf("Control string %q", seqString(seq))})
+
+		case ansi.HasApcPrefix(seq):
+			switch {
+			case ansi.HasPrefix(p.Data(), []byte("G")):
+				//nolint: godox
+				// TODO: Kitty graphics.
+			}
+
+			emit(event{kind: "APC", seq: seq})
+
+		case ansi.HasEscPrefix(seq):
+			if len(seq) == 1 {
+				// just an ESC
+				emit(event{kind: "Ctrl", seq: seq, desc: "Escape"})
+				break
+			}
+
+			emit(explained("ESC", seq, escHandler, nil, true))
+
+		case width == 0 && len(seq) == 1:
+			// control code
+			emit(event{kind: "Ctrl", seq: seq, desc: ctrlCodes[seq[0]]})
+			sess.control(seq[0])
+
+		case width > 0:
+			// Text
+			if text < 0 {
+				text = offset
+			}
+
+		default:
+			emit(event{seq: seq, desc: fmt.Sprintf("Unknown %q", seq)})
+		}
+
+		offset += n
+		state = newState
+	}
+
+	flush()
+}
+
+// explain looks up the handler for the sequence last decoded by p, or the
+// alternate one if it applies, and returns it with its explanation. When
+// variants is set, sequences that are only registered with a different
+// prefix or intermediate are reported as an unrecognized variant of the
+// registered one, instead of being unhandled.
+func explain(reg registry, alts map[int]alternate, s *session, p *ansi.Parser, variants bool) (handler, string, error) {
+	if a, ok := alts[p.Command()]; ok && a.applies(s, p) {
+		desc, err := a.fn(s, p)
+		return a.handler, desc, err
+	}
+	h, ok := reg[p.Command()]
+	if ok {
+		desc, err := h.fn(s, p)
+		return h, desc, err
+	}
+	if variants {
+		if h, ok := reg.variant(p.Command()); ok {
+			return handler{}, "", fmt.Errorf("%w of %s", errVariant, h.name)
+		}
+	}
+	return handler{}, "", errUnhandled
+}
+
+// seqString returns the sequence as a Go-escaped string, without its
+// introducer and terminator.
+func seqString(seq []byte) string {
+	s := quote(seq)
+
+	// Trim introducers and terminators
+	// CSI
+	s = strings.TrimPrefix(s, "\\x9b")
+	s = strings.TrimPrefix(s, "\\x1b[")
+	// DCS
+	s = strings.TrimPrefix(s, "\\x90")
+	s = strings.TrimPrefix(s, "\\x1bP")
+	// OSC
+	s = strings.TrimPrefix(s, "\\x9d")
+	s = strings.TrimPrefix(s, "\\x1b]")
+	// BEL
+	if !bytes.Equal(seq, []byte{ansi.BEL}) {
+		// Remove only if not a literal bell
+		s = strings.TrimSuffix(s, "\\a")
+	}
+	// SOS
+	s = strings.TrimPrefix(s, "\\x98")
+	s = strings.TrimPrefix(s, "\\x1bX")
+	// PM
+	s = strings.TrimPrefix(s, "\\x9e")
+	s = strings.TrimPrefix(s, "\\x1b^")
+	// APC
+	s = strings.TrimPrefix(s, "\\x9f")
+	s = strings.TrimPrefix(s, "\\x1b_")
+	// ESC
+	if !bytes.Equal(seq, []byte{ansi.ESC}) {
+		// Remove only if not a standalone ESC
+		s = strings.TrimPrefix(s, "\\x1b")
+	}
+	// ST
+	if !bytes.Equal(seq, []byte{ansi.ST}) {
+		// Remove only if accompanied by a sequence introducer
+		s = strings.TrimSuffix(s, "\\x9c")
+	}
+	s = strings.TrimSuffix(s, "\\x1b\\\\")
+	return s
+}
+
+// quote returns the sequence as a Go-escaped string, without quotes.
+func quote(seq []byte) string {
+	s := fmt.Sprintf("%q", seq)
+	s = strings.TrimPrefix(s, `"`)
+	s = strings.TrimSuffix(s, `"`)
+	return s
+}
+
+// is reports whether the event is a sequence of the given kind and command.
+func (e event) is(kind string, cmd int) bool {
+	return e.kind == kind && int(e.cmd) == cmd
+}
+
+// param returns the parameter at the given index, or def if it's missing.
+func (e event) param(i, def int) int {
+	if i >= len(e.params) {
+		return def
+	}
+	return e.params[i].Param(def)
+}
+
+// hasParam reports whether any of the parameters of the event equals n.
+func (e event) hasParam(n int) bool {
+	for _, p := range e.params {
+		if p.Param(-1) == n {
+			return true
+		}
+	}
+	return false
+}
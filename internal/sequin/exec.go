@@ -0,0 +1,198 @@
+package sequin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/xpty"
+)
+
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// capture is the output of a command, as read from its pty.
+type capture struct {
+	args          []string
+	start         time.Time
+	width, height int
+
+	// chunks holds every read from the pty, with its time since start.
+	chunks []chunk
+
+	// timed is set when the chunks are to be shown with their time: when
+	// asked, or when there's more than the output.
+	timed bool
+}
+
+// output returns everything the command wrote.
+func (c capture) output() []byte {
+	var out []byte
+	for _, ch := range c.chunks {
+		if ch.stream == streamOutput {
+			out = append(out, ch.data...)
+		}
+	}
+	return out
+}
+
+// execute runs the command with the terminal and the script set by the
+// flags, and records it. The error is an *exec.ExitError if the command
+// failed, and the capture is complete all the same.
+func execute(ctx context.Context, args []string) (capture, error) {
+	s, err := newScript()
+	if err != nil {
+		return capture{}, err
+	}
+	t, err := newTerminal()
+	if err != nil {
+		return capture{}, err
+	}
+	c, err := executeCommand(ctx, args, t, s)
+	if err != nil && !errors.As(err, new(*exec.ExitError)) {
+		return c, err
+	}
+	if err := record(c); err != nil {
+		return c, err
+	}
+	c.timed = timestamps != "" || s.scripted() || s.timeout > 0 || t.stderr
+	return c, err
+}
+
+// drainTimeout is how long the output is read after the command exits, in
+// case something it started still holds the pty.
+const drainTimeout = time.Second
+
+// executeCommand runs the command in a pty of the terminal, drives it with
+// the script, and captures its output, the keys, and the resizes. It returns
+// once all the output is read, with an *exec.ExitError if the command failed.
+//
+//nolint:wrapcheck,cyclop
+func executeCommand(ctx context.Context, args []string, t terminal, s script) (capture, error) {
+	c := capture{args: args, width: t.width, height: t.height}
+
+	pty, err := xpty.NewPty(t.width, t.height)
+	if err != nil {
+		return c, err
+	}
+	defer func() {
+		_ = pty.Close()
+	}()
+
+	cmdCtx := ctx
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(cmdCtx, args[0], args[1:]...) //nolint: gosec
+	cmd.Env = t.env
+
+	var stderr, stderrW *os.File
+	if t.stderr {
+		stderr, stderrW, err = os.Pipe()
+		if err != nil {
+			return c, err
+		}
+		defer stderr.Close() //nolint:errcheck
+		cmd.Stderr = stderrW
+	}
+
+	c.start = time.Now()
+	err = pty.Start(cmd)
+	// Once the command has its ends of the pty and the pipe, ours are
+	// closed, so that reading stops when the command and its children exit.
+	if stderrW != nil {
+		_ = stderrW.Close()
+	}
+	if err != nil {
+		return c, err
+	}
+	if u, ok := pty.(*xpty.UnixPty); ok {
+		_ = u.Slave().Close()
+	}
+
+	var mu sync.Mutex
+	add := func(stream string, data []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		c.chunks = append(c.chunks, chunk{stream: stream, at: time.Since(c.start), data: data})
+	}
+	var readers sync.WaitGroup
+	read := func(r io.Reader, stream string) {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			buf := make([]byte, 32*1024) //nolint:mnd
+			for {
+				n, err := r.Read(buf)
+				if n > 0 {
+					add(stream, append([]byte(nil), buf[:n]...))
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	read(pty, streamOutput)
+	if stderr != nil {
+		read(stderr, streamError)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for _, key := range s.keys {
+			select {
+			case <-done:
+				return
+			case <-time.After(s.delay):
+			}
+			add(streamInput, []byte(key))
+			if _, err := pty.Write([]byte(key)); err != nil {
+				return
+			}
+		}
+	}()
+	for _, r := range s.resizes {
+		t := time.AfterFunc(r.at, func() {
+			if pty.Resize(r.width, r.height) == nil {
+				add(streamResize, []byte(fmt.Sprintf("%dx%d", r.width, r.height)))
+			}
+		})
+		defer t.Stop()
+	}
+
+	err = xpty.WaitProcess(cmdCtx, cmd)
+	drained := make(chan struct{})
+	go func() {
+		readers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(drainTimeout):
+	}
+
+	switch {
+	case err != nil && errors.Is(cmdCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil:
+		// Quitting after the timeout is expected.
+		add(streamMarker, []byte("timeout after "+s.timeout.String()))
+		err = nil
+	case cmd.ProcessState != nil:
+		add(streamExit, []byte(strconv.Itoa(cmd.ProcessState.ExitCode())))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	c.chunks = append([]chunk(nil), c.chunks...)
+	return c, err
+}
@@ -0,0 +1,97 @@
+package sequin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	onlyKinds    []string
+	excludeKinds []string
+	matchPattern string
+)
+
+// filter selects events by their kind, and by a pattern matched against
+// their explanation.
+type filter struct {
+	only    map[string]bool
+	exclude map[string]bool
+	match   *regexp.Regexp
+}
+
+// newFilter returns the filter set by the --only, --exclude, and --match
+// flags.
+func newFilter() (filter, error) {
+	f := filter{
+		only:    kindSet(onlyKinds),
+		exclude: kindSet(excludeKinds),
+	}
+	if matchPattern != "" {
+		re, err := regexp.Compile(matchPattern)
+		if err != nil {
+			return f, fmt.Errorf("invalid pattern: %w", err)
+		}
+		f.match = re
+	}
+	return f, nil
+}
+
+// kindSet returns the given kinds as a lowercase set. Unknown sequences,
+// which have no kind, can be selected as "unknown".
+func kindSet(kinds []string) map[string]bool {
+	if len(kinds) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(kinds))
+	for _, k := range kinds {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == strings.ToLower(unknown) {
+			k = ""
+		}
+		set[k] = true
+	}
+	return set
+}
+
+// keep reports whether the event passes the filter.
+func (f filter) keep(e event) bool {
+	return f.keepKind(e.kind) && f.matches(e)
+}
+
+// keepKind reports whether events of the kind pass --only and --exclude.
+func (f filter) keepKind(kind string) bool {
+	kind = strings.ToLower(kind)
+	if f.only != nil && !f.only[kind] {
+		return false
+	}
+	return !f.exclude[kind]
+}
+
+// matches reports whether the event matches the pattern, if any.
+func (f filter) matches(e event) bool {
+	return f.match == nil || f.match.MatchString(e.String())
+}
+
+// String returns the event as a single line of plain text: its kind, the
+// sequence, its mnemonic, and its explanation.
+func (e event) String() string {
+	kind := e.kind
+	if kind == "" {
+		kind = unknown
+	}
+	s := kind + " " + seqString(e.seq)
+	if e.name != "" {
+		s += " (" + e.name + ")"
+	}
+	return s + ": " + e.explanation()
+}
+
+// explanation returns the explanation of the event, or the reason it could
+// not be explained.
+func (e event) explanation() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return e.desc
+}
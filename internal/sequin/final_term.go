@@ -1,5 +1,5 @@
 // https://github.com/gnachman/iterm2-website/blob/master/source/_includes/3.4/documentation-escape-codes.md#shell-integrationfinalterm
-package main
+package sequin
 
 import (
 	"bytes"
@@ -9,7 +9,7 @@ import (
 )
 
 //nolint:mnd
-func handleFinalTerm(p *ansi.Parser) (string, error) {
+func handleFinalTerm(_ *session, p *ansi.Parser) (string, error) {
 	parts := bytes.Split(p.Data(), []byte{';'})
 
 	if len(parts) < 2 {
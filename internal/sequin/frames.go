@@ -0,0 +1,124 @@
+package sequin
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	frames      bool
+	renderDiffs bool
+)
+
+// frame is the part of a stream that draws a single update of the screen.
+type frame struct {
+	events []event
+}
+
+// bytes returns the number of bytes in the frame.
+func (f frame) bytes() int {
+	var n int
+	for _, e := range f.events {
+		if e.onScreen() {
+			n += len(e.seq)
+		}
+	}
+	return n
+}
+
+// sequences returns the number of sequences and control codes in the frame.
+func (f frame) sequences() int {
+	var n int
+	for _, e := range f.events {
+		if e.kind != "Text" && e.onScreen() {
+			n++
+		}
+	}
+	return n
+}
+
+// splitFrames splits the events into frames. Frames start with synchronized
+// output (mode 2026), or, outside of it, when the cursor moves home or the
+// screen is erased. A new frame only starts once the current one has drawn
+// some text, so that the sequences preparing a frame stay together.
+func splitFrames(events []event) []frame {
+	var frames []frame
+	var cur frame
+	var synced, drawn bool
+	for _, e := range events {
+		var boundary bool
+		switch {
+		case e.is("CSI", ansi.Command('?', 0, 'h')) && e.hasParam(ansi.ModeSynchronizedOutput.Mode()):
+			boundary = true
+			synced = true
+		case e.is("CSI", ansi.Command('?', 0, 'l')) && e.hasParam(ansi.ModeSynchronizedOutput.Mode()):
+			synced = false
+		case e.is("CSI", ansi.Command(0, 0, 'H')) && e.param(0, 1) <= 1 && e.param(1, 1) <= 1:
+			boundary = !synced
+		case e.is("CSI", ansi.Command(0, 0, 'J')) && e.param(0, 0) == 2: //nolint:mnd
+			boundary = !synced
+		}
+		if boundary && drawn {
+			frames = append(frames, cur)
+			cur, drawn = frame{}, false
+		}
+		cur.events = append(cur.events, e)
+		if e.kind == "Text" {
+			drawn = true
+		}
+	}
+	if len(cur.events) > 0 {
+		frames = append(frames, cur)
+	}
+	return frames
+}
+
+// printFrames writes the events of each frame, preceded by a header with
+// its size and the rows it redrew on g, the screen they are drawn on. When
+// renderDiffs is set, the rows that changed are shown after the events.
+func printFrames(w io.Writer, t theme, events []event, f filter, o printOptions, g *grid) {
+	for i, fr := range splitFrames(events) {
+		before := g.lines()
+		g.resetTouched()
+		for _, e := range fr.events {
+			g.apply(e)
+		}
+		after := g.lines()
+
+		var changed []int
+		for y := range max(len(before), len(after)) {
+			if lineAt(before, y) != lineAt(after, y) {
+				changed = append(changed, y)
+			}
+		}
+
+		_, _ = fmt.Fprintln(w, t.explanation.Bold(true).Render(fmt.Sprintf(
+			"Frame %d: %d bytes, %d sequences, redrew %d rows, %d changed",
+			i+1, fr.bytes(), fr.sequences(), len(g.touched), len(changed),
+		)))
+		for _, e := range fr.events {
+			if f.keep(e) {
+				printEvent(w, t, e, o)
+			}
+		}
+		if !renderDiffs {
+			continue
+		}
+		for _, y := range changed {
+			_, _ = fmt.Fprintln(w, t.error.Render(strings.TrimRight(fmt.Sprintf("%5d - %s", y+1, lineAt(before, y)), " ")))
+			_, _ = fmt.Fprintln(w, t.explanation.Render(strings.TrimRight(fmt.Sprintf("%5d + %s", y+1, lineAt(after, y)), " ")))
+		}
+	}
+}
+
+// lineAt returns the line at y, or an empty one past the end of a screen that
+// was resized.
+func lineAt(lines []string, y int) string {
+	if y < len(lines) {
+		return lines[y]
+	}
+	return ""
+}
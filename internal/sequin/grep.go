@@ -0,0 +1,101 @@
+package sequin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/charmbracelet/colorprofile"
+	"github.com/spf13/cobra"
+)
+
+func grepCmd() *cobra.Command {
+	var after, before, around int
+	c := &cobra.Command{
+		Use:   "grep <pattern> [file]",
+		Short: "Show the sequences whose explanation matches a pattern",
+		Args:  cobra.RangeArgs(1, 2), //nolint:mnd
+		Example: `
+# Find everything touching mouse modes in a golden file:
+sequin grep 'mode.*mouse' ./testdata/MyCuteApp.golden
+
+# Show two events of context around each hyperlink:
+sequin grep -C 2 OSC8 <file
+	`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			re, err := regexp.Compile(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid pattern: %w", err)
+			}
+			in, err := readInput(cmd, args[1:])
+			if err != nil {
+				return err
+			}
+			if around > 0 {
+				after, before = around, around
+			}
+			w := colorprofile.NewWriter(cmd.OutOrStdout(), os.Environ())
+			return grep(w, in, re, before, after)
+		},
+	}
+	c.Flags().IntVarP(&after, "after-context", "A", 0, "show this many events after each match")
+	c.Flags().IntVarP(&before, "before-context", "B", 0, "show this many events before each match")
+	c.Flags().IntVarP(&around, "context", "C", 0, "show this many events around each match")
+	return c
+}
+
+// grep writes the events matching re, with offsets, and the given number of
+// surrounding events. Non-adjacent groups of events are separated by "--".
+func grep(w io.Writer, in []byte, re *regexp.Regexp, before, after int) error {
+	f, err := newFilter()
+	if err != nil {
+		return err
+	}
+
+	var events []event
+	decode(in, func(e event) {
+		if f.keep(e) {
+			events = append(events, e)
+		}
+	})
+
+	// Mark the events to show: each match and its context.
+	show := make([]bool, len(events))
+	for i, e := range events {
+		if !re.MatchString(e.String()) {
+			continue
+		}
+		for j := max(0, i-before); j <= min(len(events)-1, i+after); j++ {
+			show[j] = true
+		}
+	}
+
+	t := newTheme()
+	o := flagOptions(time.Time{})
+	o.offsets = true
+	last := -1
+	for i, e := range events {
+		if !show[i] {
+			continue
+		}
+		if last >= 0 && last != i-1 {
+			_, _ = fmt.Fprintln(w, t.sequence.Render("--"))
+		}
+		printEvent(w, t, e, o)
+		last = i
+	}
+	return nil
+}
+
+// readInput returns the contents of the file named by args, or the standard
+// input of the command when no file is given.
+func readInput(cmd *cobra.Command, args []string) ([]byte, error) {
+	if len(args) == 0 || args[0] == "-" {
+		in, err := io.ReadAll(cmd.InOrStdin())
+		return in, err //nolint:wrapcheck
+	}
+	in, err := os.ReadFile(args[0])
+	return in, err //nolint:wrapcheck
+}
@@ -0,0 +1,387 @@
+package sequin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+const tabWidth = 8
+
+// grid is a minimal model of a terminal screen. It only tracks the text on
+// the screen and the cursor, which is enough to tell what a stream displays.
+// Writing to it applies the given sequences and text.
+type grid struct {
+	width, height int
+
+	cells [][]string
+	x, y  int
+
+	// top and bottom are the scrolling region, zero-based and inclusive.
+	top, bottom int
+
+	// saved holds the cursor saved with DECSC or SCOSC.
+	savedX, savedY int
+
+	// main holds the main screen while the alternate screen is active.
+	main [][]string
+
+	// touched marks the rows written to since the last call to reset.
+	touched map[int]bool
+
+	// edge is set when the cursor runs into the right or bottom edge of
+	// the screen, where a larger one would have put it elsewhere.
+	edge bool
+
+	state byte
+	p     *ansi.Parser
+}
+
+func newGrid(width, height int) *grid {
+	g := &grid{
+		width:   width,
+		height:  height,
+		bottom:  height - 1,
+		touched: map[int]bool{},
+		p:       ansi.NewParser(),
+	}
+	g.cells = g.blank()
+	return g
+}
+
+func (g *grid) blank() [][]string {
+	cells := make([][]string, g.height)
+	for y := range cells {
+		cells[y] = g.blankRow()
+	}
+	return cells
+}
+
+func (g *grid) blankRow() []string {
+	row := make([]string, g.width)
+	for x := range row {
+		row[x] = " "
+	}
+	return row
+}
+
+// Write applies the sequences and text in b to the screen.
+func (g *grid) Write(b []byte) (int, error) {
+	n := len(b)
+	for len(b) > 0 {
+		seq, width, m, newState := ansi.DecodeSequence(b, g.state, g.p)
+		switch {
+		case ansi.HasCsiPrefix(seq):
+			g.csi()
+		case ansi.HasEscPrefix(seq) && len(seq) > 1:
+			g.esc()
+		case width == 0 && len(seq) == 1:
+			g.control(seq[0])
+		case width > 0:
+			g.print(string(seq), width)
+		}
+		b = b[m:]
+		g.state = newState
+	}
+	return n, nil
+}
+
+// apply applies the event to the screen: what is written to it, and
+// resizes.
+func (g *grid) apply(e event) {
+	switch {
+	case e.stream == streamResize:
+		if width, height, ok := parseSize(string(e.seq)); ok {
+			g.resize(width, height)
+		}
+	case e.onScreen():
+		_, _ = g.Write(e.seq)
+	}
+}
+
+// resize changes the size of the screen. What doesn't fit is cut off, from
+// the top if the cursor would be below the screen, and the scrolling region
+// is reset, as terminals do.
+func (g *grid) resize(width, height int) {
+	g.width, g.height = width, height
+	shift := max(0, g.y-height+1)
+	fit := func(rows [][]string) [][]string {
+		if rows == nil {
+			return nil
+		}
+		rows = rows[min(shift, len(rows)):]
+		out := g.blank()
+		for y := range min(len(rows), height) {
+			copy(out[y], rows[y])
+		}
+		return out
+	}
+	g.cells, g.main = fit(g.cells), fit(g.main)
+	g.top, g.bottom = 0, height-1
+	g.x, g.y = min(g.x, width), g.y-shift
+	g.savedX, g.savedY = min(g.savedX, width-1), min(g.savedY, height-1)
+	for y := range height {
+		g.touched[y] = true
+	}
+}
+
+// parseSize parses a size like 100x30, as in resize events.
+func parseSize(s string) (width, height int, ok bool) {
+	w, h, ok := strings.Cut(s, "x")
+	width, werr := strconv.Atoi(w)
+	height, herr := strconv.Atoi(h)
+	if !ok || werr != nil || herr != nil || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// lines returns the text of each row, without trailing blanks.
+func (g *grid) lines() []string {
+	lines := make([]string, g.height)
+	for y, row := range g.cells {
+		lines[y] = strings.TrimRight(strings.Join(row, ""), " ")
+	}
+	return lines
+}
+
+// String returns the text on the screen, without trailing blank lines.
+func (g *grid) String() string {
+	return strings.TrimRight(strings.Join(g.lines(), "\n"), "\n")
+}
+
+// resetTouched forgets which rows were written to.
+func (g *grid) resetTouched() {
+	g.touched = map[int]bool{}
+}
+
+func (g *grid) print(s string, width int) {
+	g.edge = g.edge || g.x+width >= g.width
+	if g.x+width > g.width {
+		// Autowrap.
+		g.x = 0
+		g.lineFeed()
+	}
+	g.cells[g.y][g.x] = s
+	for i := 1; i < width && g.x+i < g.width; i++ {
+		// Wide characters occupy the following cells.
+		g.cells[g.y][g.x+i] = ""
+	}
+	g.touched[g.y] = true
+	g.x += width
+}
+
+func (g *grid) control(c byte) {
+	switch c {
+	case ansi.BS:
+		g.x = max(0, g.x-1)
+	case ansi.HT:
+		next := (g.x/tabWidth + 1) * tabWidth
+		g.edge = g.edge || next > g.width-1
+		g.x = min(g.width-1, next)
+	case ansi.LF, ansi.VT, ansi.FF:
+		g.lineFeed()
+	case ansi.CR:
+		g.x = 0
+	}
+}
+
+func (g *grid) lineFeed() {
+	g.edge = g.edge || g.y == g.height-1 || g.y == g.bottom && g.bottom == g.height-1
+	if g.y == g.bottom {
+		g.scrollUp(1)
+		return
+	}
+	g.y = min(g.height-1, g.y+1)
+}
+
+// scrollUp scrolls the scrolling region up by n lines. Scrolling by more
+// than the region clears it.
+func (g *grid) scrollUp(n int) {
+	for range min(n, g.bottom-g.top+1) {
+		copy(g.cells[g.top:g.bottom], g.cells[g.top+1:g.bottom+1])
+		g.cells[g.bottom] = g.blankRow()
+	}
+	g.touchRegion()
+}
+
+// scrollDown scrolls the scrolling region down by n lines. Scrolling by
+// more than the region clears it.
+func (g *grid) scrollDown(n int) {
+	for range min(n, g.bottom-g.top+1) {
+		copy(g.cells[g.top+1:g.bottom+1], g.cells[g.top:g.bottom])
+		g.cells[g.top] = g.blankRow()
+	}
+	g.touchRegion()
+}
+
+func (g *grid) touchRegion() {
+	for y := g.top; y <= g.bottom; y++ {
+		g.touched[y] = true
+	}
+}
+
+func (g *grid) clearRow(y, from, to int) {
+	for x := max(0, from); x < min(g.width, to); x++ {
+		g.cells[y][x] = " "
+	}
+	g.touched[y] = true
+}
+
+func (g *grid) moveTo(x, y int) {
+	g.edge = g.edge || x > g.width-1 || y > g.height-1
+	g.x = max(0, min(g.width-1, x))
+	g.y = max(0, min(g.height-1, y))
+}
+
+func (g *grid) esc() {
+	switch ansi.Cmd(g.p.Command()).Final() {
+	case '7':
+		g.savedX, g.savedY = g.x, g.y
+	case '8':
+		g.moveTo(g.savedX, g.savedY)
+	case 'D':
+		g.lineFeed()
+	case 'E':
+		g.x = 0
+		g.lineFeed()
+	case 'M':
+		if g.y == g.top {
+			g.scrollDown(1)
+		} else {
+			g.moveTo(g.x, g.y-1)
+		}
+	}
+}
+
+//nolint:mnd,cyclop
+func (g *grid) csi() {
+	cmd := ansi.Cmd(g.p.Command())
+	n, _ := g.p.Param(0, 1)
+	n = max(1, n)
+
+	if cmd.Prefix() == '?' {
+		switch cmd.Final() {
+		case 'h', 'l':
+			for _, param := range g.p.Params() {
+				switch param.Param(0) {
+				case 47, 1047, 1049:
+					g.altScreen(cmd.Final() == 'h')
+				}
+			}
+		}
+		return
+	}
+	if cmd.Prefix() != 0 || cmd.Intermediate() != 0 {
+		return
+	}
+
+	switch cmd.Final() {
+	case 'A':
+		g.moveTo(g.x, g.y-n)
+	case 'B', 'e':
+		g.moveTo(g.x, g.y+n)
+	case 'C', 'a':
+		g.moveTo(g.x+n, g.y)
+	case 'D':
+		g.moveTo(g.x-n, g.y)
+	case 'E':
+		g.moveTo(0, g.y+n)
+	case 'F':
+		g.moveTo(0, g.y-n)
+	case 'G', '`':
+		g.moveTo(n-1, g.y)
+	case 'd':
+		g.moveTo(g.x, n-1)
+	case 'H', 'f':
+		row, _ := g.p.Param(0, 1)
+		col, _ := g.p.Param(1, 1)
+		g.moveTo(max(1, col)-1, max(1, row)-1)
+	case 'J':
+		switch m, _ := g.p.Param(0, 0); m {
+		case 0:
+			g.clearRow(g.y, g.x, g.width)
+			for y := g.y + 1; y < g.height; y++ {
+				g.clearRow(y, 0, g.width)
+			}
+		case 1:
+			g.clearRow(g.y, 0, g.x+1)
+			for y := range g.y {
+				g.clearRow(y, 0, g.width)
+			}
+		case 2, 3:
+			for y := range g.height {
+				g.clearRow(y, 0, g.width)
+			}
+		}
+	case 'K':
+		switch m, _ := g.p.Param(0, 0); m {
+		case 0:
+			g.clearRow(g.y, g.x, g.width)
+		case 1:
+			g.clearRow(g.y, 0, g.x+1)
+		case 2:
+			g.clearRow(g.y, 0, g.width)
+		}
+	case 'X':
+		g.clearRow(g.y, g.x, g.x+n)
+	case '@':
+		row := g.cells[g.y]
+		n = min(n, g.width-g.x)
+		copy(row[g.x+n:], row[g.x:])
+		g.clearRow(g.y, g.x, g.x+n)
+	case 'P':
+		row := g.cells[g.y]
+		n = min(n, g.width-g.x)
+		copy(row[g.x:], row[g.x+n:])
+		g.clearRow(g.y, g.width-n, g.width)
+	case 'L', 'M':
+		if g.y < g.top || g.y > g.bottom {
+			break
+		}
+		top := g.top
+		g.top = g.y
+		if cmd.Final() == 'L' {
+			g.scrollDown(n)
+		} else {
+			g.scrollUp(n)
+		}
+		g.top = top
+	case 'S':
+		g.scrollUp(n)
+	case 'T':
+		g.scrollDown(n)
+	case 'r':
+		top, _ := g.p.Param(0, 1)
+		bottom, _ := g.p.Param(1, g.height)
+		if bottom == 0 {
+			bottom = g.height
+		}
+		if top = max(1, top); top < bottom && bottom <= g.height {
+			g.top, g.bottom = top-1, bottom-1
+			g.moveTo(0, 0)
+		}
+	case 's':
+		g.savedX, g.savedY = g.x, g.y
+	case 'u':
+		g.moveTo(g.savedX, g.savedY)
+	}
+}
+
+// altScreen switches to or from the alternate screen.
+func (g *grid) altScreen(on bool) {
+	switch {
+	case on && g.main == nil:
+		g.main = g.cells
+		g.cells = g.blank()
+	case !on && g.main != nil:
+		g.cells = g.main
+		g.main = nil
+	default:
+		return
+	}
+	for y := range g.height {
+		g.touched[y] = true
+	}
+}
@@ -0,0 +1,207 @@
+package sequin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+var csiHandlers = registry{
+	ansi.Command(0, 0, 'm'): {"SGR", handleSgr, docSGR},
+	ansi.Command(0, 0, 'c'): {"DA1", printf("Request primary device attributes"), docDA1},
+
+	// kitty
+	ansi.Command('?', 0, 'u'): {"KITTYQUERY", handleKitty, docKITTYQUERY},
+	ansi.Command('>', 0, 'u'): {"KITTYPUSH", handleKitty, docKITTYPUSH},
+	ansi.Command('<', 0, 'u'): {"KITTYPOP", handleKitty, docKITTYPOP},
+	ansi.Command('=', 0, 'u'): {"KITTYSET", handleKitty, docKITTYSET},
+
+	// cursor
+	ansi.Command(0, 0, 'A'):   {"CUU", handleCursor, docCUU},
+	ansi.Command(0, 0, 'B'):   {"CUD", handleCursor, docCUD},
+	ansi.Command(0, 0, 'C'):   {"CUF", handleCursor, docCUF},
+	ansi.Command(0, 0, 'D'):   {"CUB", handleCursor, docCUB},
+	ansi.Command(0, 0, 'E'):   {"CNL", handleCursor, docCNL},
+	ansi.Command(0, 0, 'F'):   {"CPL", handleCursor, docCPL},
+	ansi.Command(0, 0, 'H'):   {"CUP", handleCursor, docCUP},
+	ansi.Command('?', 0, 'n'): {"DECDSR", handleCursor, docDECDSR},
+	ansi.Command(0, 0, 'n'):   {"DSR", handleCursor, docDSR},
+	ansi.Command(0, 0, 's'):   {"SCOSC", handleCursor, docSCOSC},
+	ansi.Command(0, 0, 'u'):   {"SCORC", handleCursor, docSCORC},
+	ansi.Command(0, ' ', 'q'): {"DECSCUSR", handleCursor, docDECSCUSR},
+
+	// screen
+	ansi.Command(0, 0, 'r'):   {"DECSTBM", handleScreen, docDECSTBM},
+	ansi.Command(0, 0, 'J'):   {"ED", handleScreen, docED},
+	ansi.Command(0, 0, 'K'):   {"EL", handleLine, docEL},
+	ansi.Command(0, 0, 'L'):   {"IL", handleLine, docIL},
+	ansi.Command(0, 0, 'M'):   {"DL", handleLine, docDL},
+	ansi.Command(0, 0, 'S'):   {"SU", handleLine, docSU},
+	ansi.Command(0, 0, 'T'):   {"SD", handleLine, docSD},
+	ansi.Command('?', 0, 'J'): {"DECSED", handleScreen, docDECSED},
+	ansi.Command('?', 0, 'K'): {"DECSEL", handleLine, docDECSEL},
+
+	// rectangular areas and protection
+	ansi.Command(0, '$', 'v'): {"DECCRA", handleRect, docDECCRA},
+	ansi.Command(0, '$', 'x'): {"DECFRA", handleRect, docDECFRA},
+	ansi.Command(0, '$', 'z'): {"DECERA", handleRect, docDECERA},
+	ansi.Command(0, '$', '{'): {"DECSERA", handleRect, docDECSERA},
+	ansi.Command(0, '$', 'r'): {"DECCARA", handleRect, docDECCARA},
+	ansi.Command(0, '$', 't'): {"DECRARA", handleRect, docDECRARA},
+	ansi.Command(0, '*', 'x'): {"DECSACE", handleRect, docDECSACE},
+	ansi.Command(0, '*', 'y'): {"DECRQCRA", handleRect, docDECRQCRA},
+	ansi.Command(0, '"', 'q'): {"DECSCA", handleRect, docDECSCA},
+
+	// modes
+	ansi.Command(0, '$', 'p'):   {"DECRQM", handleMode, docDECRQM},
+	ansi.Command('?', '$', 'p'): {"DECRQM", handleMode, docDECRQM},
+	ansi.Command('?', 0, 'h'):   {"DECSET", handleMode, docDECSET},
+	ansi.Command('?', 0, 'l'):   {"DECRST", handleMode, docDECRST},
+	ansi.Command(0, 0, 'h'):     {"SM", handleMode, docSM},
+	ansi.Command(0, 0, 'l'):     {"RM", handleMode, docRM},
+
+	// resets
+	ansi.Command(0, '!', 'p'): {"DECSTR", handleReset, docDECSTR},
+	ansi.Command(0, '"', 'p'): {"DECSCL", handleReset, docDECSCL},
+
+	// xterm
+	ansi.Command('>', 0, 'q'): {"XTVERSION", handleXT, docXTVERSION},
+	ansi.Command('>', 0, 'm'): {"XTMODKEYS", handleXTModKeys, docXTMODKEYS},
+	ansi.Command('?', 0, 'm'): {"XTQMODKEYS", handleXTModKeys, docXTQMODKEYS},
+	ansi.Command(0, '#', '{'): {"XTPUSHSGR", handleXTSgrStack, docXTPUSHSGR},
+	ansi.Command(0, '#', '}'): {"XTPOPSGR", handleXTSgrStack, docXTPOPSGR},
+	ansi.Command(0, '#', '|'): {"XTREPORTSGR", handleXTSgrStack, docXTREPORTSGR},
+	ansi.Command(0, '#', 'P'): {"XTPUSHCOLORS", handleXTColorStack, docXTPUSHCOLORS},
+	ansi.Command(0, '#', 'Q'): {"XTPOPCOLORS", handleXTColorStack, docXTPOPCOLORS},
+	ansi.Command(0, '#', 'R'): {"XTREPORTCOLORS", handleXTColorStack, docXTREPORTCOLORS},
+	ansi.Command('?', 0, 'S'): {"XTSMGRAPHICS", handleXTGraphics, docXTSMGRAPHICS},
+}
+
+// csiAlternates are the CSI sequences that share their bytes with one in
+// csiHandlers, and mean something else once the session says so: the key
+// events of the kitty keyboard protocol share CSI u with SCORC.
+var csiAlternates = map[int]alternate{
+	ansi.Command(0, 0, 'u'): {handler{"KITTYKEY", handleKittyKey, docKITTYKEY}, isKittyKey},
+}
+
+var oscHandlers = registry{
+	0:   {"OSC0", handleTitle, docOSC0},
+	1:   {"OSC1", handleTitle, docOSC1},
+	2:   {"OSC2", handleTitle, docOSC2},
+	7:   {"OSC7", handleWorkingDirectoryURL, docOSC7},
+	8:   {"OSC8", handleHyperlink, docOSC8},
+	9:   {"OSC9", handleNotify, docOSC9},
+	10:  {"OSC10", handleTerminalColor, docOSC10},
+	11:  {"OSC11", handleTerminalColor, docOSC11},
+	12:  {"OSC12", handleTerminalColor, docOSC12},
+	22:  {"OSC22", handlePointerShape, docOSC22},
+	52:  {"OSC52", handleClipboard, docOSC52},
+	110: {"OSC110", handleResetTerminalColor, docOSC110},
+	111: {"OSC111", handleResetTerminalColor, docOSC111},
+	112: {"OSC112", handleResetTerminalColor, docOSC112},
+	133: {"OSC133", handleFinalTerm, docOSC133},
+}
+
+var dcsHandlers = registry{
+	ansi.Command(0, '+', 'q'): {"XTGETTCAP", handleTermcap, docXTGETTCAP},
+}
+
+var escHandler = registry{
+	ansi.Command(0, 0, '7'): {"DECSC", printf("Save cursor"), docDECSC},
+	ansi.Command(0, 0, '8'): {"DECRC", printf("Restore cursor"), docDECRC},
+	ansi.Command(0, 0, '>'): {"DECKPNM", printf("Normal Keypad"), docDECKPNM},
+	ansi.Command(0, 0, '='): {"DECKPAM", printf("Application Keypad"), docDECKPAM},
+
+	// character sets
+	ansi.Command(0, 0, 'n'):   {"LS2", handleCharset, docLS2},
+	ansi.Command(0, 0, 'o'):   {"LS3", handleCharset, docLS3},
+	ansi.Command(0, '(', 'B'): {"SCS", handleCharset, docSCS},
+	ansi.Command(0, '(', 'A'): {"SCS", handleCharset, docSCS},
+	ansi.Command(0, '(', '0'): {"SCS", handleCharset, docSCS},
+	ansi.Command(0, ')', 'B'): {"SCS", handleCharset, docSCS},
+	ansi.Command(0, ')', 'A'): {"SCS", handleCharset, docSCS},
+	ansi.Command(0, ')', '0'): {"SCS", handleCharset, docSCS},
+	ansi.Command(0, '*', 'B'): {"SCS", handleCharset, docSCS},
+	ansi.Command(0, '*', 'A'): {"SCS", handleCharset, docSCS},
+	ansi.Command(0, '*', '0'): {"SCS", handleCharset, docSCS},
+	ansi.Command(0, '+', 'B'): {"SCS", handleCharset, docSCS},
+	ansi.Command(0, '+', 'A'): {"SCS", handleCharset, docSCS},
+	ansi.Command(0, '+', '0'): {"SCS", handleCharset, docSCS},
+
+	// C0/7-bit ASCII variant of ST.
+	// C1/8-bit extended ASCII variant handled as Ctrl.
+	ansi.Command(0, 0, '\\'): {"ST", printf("String terminator"), docST},
+}
+
+var (
+	errUnhandled = errors.New("TODO: unhandled sequence")
+	errInvalid   = errors.New("invalid sequence")
+	errVariant   = errors.New("unrecognized variant")
+	errStrict    = errors.New("strict mode")
+	errDiffer    = errors.New("streams differ")
+)
+
+// handlerFn explains the sequence last decoded by the parser. It may read and
+// update the session to account for state set by earlier sequences.
+type handlerFn = func(*session, *ansi.Parser) (string, error)
+
+// handler explains a single sequence, identified by its mnemonic, and
+// documents it for sequin list and sequin doc.
+type handler struct {
+	name string
+	fn   handlerFn
+	doc  *doc
+}
+
+// alternate is a handler for a command registered to another one, which takes
+// over when applies reports true for the session and the sequence.
+type alternate struct {
+	handler
+	applies func(*session, *ansi.Parser) bool
+}
+
+// alternates returns the handlers of the alternates, as a registry.
+func alternates(alts map[int]alternate) registry {
+	r := make(registry, len(alts))
+	for cmd, a := range alts {
+		r[cmd] = a.handler
+	}
+	return r
+}
+
+// registry maps the packed command of a sequence, as returned by
+// [ansi.Parser.Command], to its handler. Keys must declare the exact prefix,
+// intermediate and final bytes of the sequence, see [ansi.Command].
+type registry map[int]handler
+
+// variant returns the handler registered for the same final byte as cmd,
+// ignoring its prefix and intermediate. Handlers without a prefix or
+// intermediate take precedence, so the plain meaning of the sequence is
+// reported.
+func (r registry) variant(cmd int) (handler, bool) {
+	final := ansi.Cmd(cmd).Final()
+	best, found := 0, false
+	for k := range r {
+		if ansi.Cmd(k).Final() != final {
+			continue
+		}
+		if !found || k < best {
+			best, found = k, true
+		}
+	}
+	return r[best], found
+}
+
+func printf(format string, v ...any) handlerFn { //nolint:unparam
+	return func(*session, *ansi.Parser) (string, error) {
+		return fmt.Sprintf(format, v...), nil
+	}
+}
+
+func default1(i int) int {
+	if i == 0 {
+		return 1
+	}
+	return i
+}
@@ -1,4 +1,4 @@
-package main
+package sequin
 
 import (
 	"bytes"
@@ -8,7 +8,7 @@ import (
 )
 
 //nolint:mnd
-func handleHyperlink(p *ansi.Parser) (string, error) {
+func handleHyperlink(_ *session, p *ansi.Parser) (string, error) {
 	parts := bytes.Split(p.Data(), []byte{';'})
 	if len(parts) != 3 {
 		// Invalid, ignore
@@ -0,0 +1,247 @@
+package sequin
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	sendKeys    []string
+	sendDelay   time.Duration
+	resizeAt    []string
+	execTimeout time.Duration
+	kittyKeys   bool
+)
+
+var (
+	errKey    = errors.New("invalid key")
+	errResize = errors.New("invalid resize")
+)
+
+// script is the input that drives an executed command: keys written with a
+// delay between them, resizes at given times, and a timeout.
+type script struct {
+	keys    []string
+	delay   time.Duration
+	resizes []resize
+	timeout time.Duration
+}
+
+// resize is a change of the size of the pty at some time after the start.
+type resize struct {
+	at            time.Duration
+	width, height int
+}
+
+// scripted reports whether the script sends anything to the command.
+func (s script) scripted() bool {
+	return len(s.keys) > 0 || len(s.resizes) > 0
+}
+
+// newScript returns the script set by the flags.
+func newScript() (script, error) {
+	s := script{delay: sendDelay, timeout: execTimeout}
+	for _, send := range sendKeys {
+		keys, err := parseKeys(send, kittyKeys)
+		if err != nil {
+			return s, err
+		}
+		s.keys = append(s.keys, keys...)
+	}
+	for _, r := range resizeAt {
+		at, size, ok := strings.Cut(r, ":")
+		d, err := time.ParseDuration(at)
+		if !ok || err != nil {
+			return s, fmt.Errorf("%w %q: expected a time and a size, like 2s:120x40", errResize, r)
+		}
+		w, h, ok := strings.Cut(size, "x")
+		width, errw := strconv.Atoi(w)
+		height, errh := strconv.Atoi(h)
+		if !ok || errw != nil || errh != nil || width <= 0 || height <= 0 {
+			return s, fmt.Errorf("%w %q: expected a size like 120x40", errResize, r)
+		}
+		s.resizes = append(s.resizes, resize{d, width, height})
+	}
+	return s, nil
+}
+
+// parseKeys returns the bytes a terminal sends for the keys, separated by
+// spaces, like "j j ctrl+c". Quoted strings are sent as text.
+func parseKeys(s string, kitty bool) ([]string, error) {
+	var keys []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] == '"' || s[0] == '`' {
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", errKey, s)
+			}
+			text, _ := strconv.Unquote(quoted)
+			keys = append(keys, text)
+			s = s[len(quoted):]
+			continue
+		}
+		name, rest, _ := strings.Cut(s, " ")
+		key, err := encodeKey(name, kitty)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		s = rest
+	}
+	return keys, nil
+}
+
+// Modifiers, as encoded in key sequences: the parameter is one more than
+// their sum.
+const (
+	modShift = 1 << iota
+	modAlt
+	modCtrl
+	modSuper
+)
+
+var modNames = map[string]int{
+	"shift": modShift,
+	"alt":   modAlt,
+	"ctrl":  modCtrl,
+	"super": modSuper,
+}
+
+// keyCodes are the keys sent as a single byte, with their code point in the
+// Kitty keyboard protocol.
+var keyCodes = map[string]rune{
+	"enter":     '\r',
+	"tab":       '\t',
+	"esc":       0x1b,
+	"escape":    0x1b,
+	"backspace": 0x7f,
+	"space":     ' ',
+}
+
+// csiKeys are the keys sent as CSI sequences, by their final byte, or their
+// number and ~.
+var csiKeys = map[string]string{
+	"up":     "A",
+	"down":   "B",
+	"right":  "C",
+	"left":   "D",
+	"home":   "H",
+	"end":    "F",
+	"insert": "2~",
+	"delete": "3~",
+	"pgup":   "5~",
+	"pgdown": "6~",
+	"f1":     "P",
+	"f2":     "Q",
+	"f3":     "R",
+	"f4":     "S",
+	"f5":     "15~",
+	"f6":     "17~",
+	"f7":     "18~",
+	"f8":     "19~",
+	"f9":     "20~",
+	"f10":    "21~",
+	"f11":    "23~",
+	"f12":    "24~",
+}
+
+// encodeKey returns the bytes a terminal sends for a key, like "enter",
+// "ctrl+c", or "alt+shift+up". Keys are encoded as xterm does, or with the
+// Kitty keyboard protocol, which can tell apart keys like ctrl+i and tab.
+//
+//nolint:mnd,cyclop
+func encodeKey(name string, kitty bool) (string, error) {
+	parts := strings.Split(name, "+")
+	key := parts[len(parts)-1]
+	if key == "" && len(parts) > 1 {
+		// The plus key, as in ctrl++.
+		key, parts = "+", parts[:len(parts)-1]
+	}
+	var mods int
+	for _, m := range parts[:len(parts)-1] {
+		bit, ok := modNames[strings.ToLower(m)]
+		if !ok {
+			return "", fmt.Errorf("%w %q: unknown modifier %q", errKey, name, m)
+		}
+		mods |= bit
+	}
+
+	if form, ok := csiKeys[strings.ToLower(key)]; ok {
+		if kitty && strings.EqualFold(key, "f3") {
+			// Kitty sends F3 as CSI 13 ~, since CSI R is a cursor position report.
+			form = "13~"
+		}
+		num, final := "1", form
+		if strings.HasSuffix(form, "~") {
+			num, final = form[:len(form)-1], "~"
+		}
+		switch {
+		case mods != 0:
+			return fmt.Sprintf("\x1b[%s;%d%s", num, mods+1, final), nil
+		case final == "~":
+			return "\x1b[" + num + "~", nil
+		case strings.Contains("PQRS", final) && !kitty:
+			return "\x1bO" + final, nil
+		}
+		return "\x1b[" + final, nil
+	}
+
+	code, ok := keyCodes[strings.ToLower(key)]
+	if !ok {
+		r, size := utf8.DecodeRuneInString(key)
+		if size != len(key) || r == utf8.RuneError {
+			return "", fmt.Errorf("%w %q: quote text to send it as is", errKey, name)
+		}
+		code = r
+	}
+
+	if kitty {
+		switch {
+		case mods == 0 && code != 0x1b:
+			// Text, enter, tab, and backspace are sent as they are.
+			return string(code), nil
+		case mods == modShift && code > ' ' && code != 0x7f:
+			return string(unicode.ToUpper(code)), nil
+		}
+		if mods == 0 {
+			return fmt.Sprintf("\x1b[%du", code), nil
+		}
+		return fmt.Sprintf("\x1b[%d;%du", unicode.ToLower(code), mods+1), nil
+	}
+
+	s := string(code)
+	if mods&modShift != 0 {
+		switch {
+		case code == '\t':
+			s = "\x1b[Z"
+		case unicode.IsLetter(code):
+			s = string(unicode.ToUpper(code))
+		default:
+			return "", fmt.Errorf("%w %q: needs --kitty-keys", errKey, name)
+		}
+	}
+	if mods&modCtrl != 0 {
+		switch c := unicode.ToUpper(code); {
+		case c == ' ':
+			s = "\x00"
+		case c >= '@' && c <= '_':
+			s = string(c & 0x1f)
+		case c == '?':
+			s = "\x7f"
+		default:
+			return "", fmt.Errorf("%w %q: needs --kitty-keys", errKey, name)
+		}
+	}
+	if mods&modSuper != 0 {
+		return "", fmt.Errorf("%w %q: needs --kitty-keys", errKey, name)
+	}
+	if mods&modAlt != 0 {
+		s = "\x1b" + s
+	}
+	return s, nil
+}
@@ -0,0 +1,155 @@
+package sequin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+//nolint:mnd
+func handleKitty(s *session, p *ansi.Parser) (string, error) {
+	flagDesc := func(flag int) string {
+		var r []string
+		if flag&1 != 0 {
+			r = append(r, "Disambiguate escape codes")
+		}
+		if flag&2 != 0 {
+			r = append(r, "Report event types")
+		}
+		if flag&4 != 0 {
+			r = append(r, "Report alternate keys")
+		}
+		if flag&8 != 0 {
+			r = append(r, "Report all keys as escape codes")
+		}
+		if flag&16 != 0 {
+			r = append(r, "Report associated text")
+		}
+		return strings.Join(r, ", ")
+	}
+	modeDesc := func(mode int) string {
+		switch mode {
+		case 1:
+			return "Set given flags and unset all others"
+		case 2:
+			return "Set given flags and keep existing flags unchanged"
+		case 3:
+			return "Unset given flags and keep existing flags unchanged"
+		default:
+			return unknown + " mode"
+		}
+	}
+
+	var first int
+	if n, ok := p.Param(0, 0); ok {
+		first = n
+	}
+
+	cmd := ansi.Cmd(p.Command())
+	switch cmd.Prefix() {
+	case '?':
+		return "Request Kitty keyboard", nil
+	case '>':
+		s.kittyFlags = append(s.kittyFlags, first)
+		if first == 0 {
+			return "Disable Kitty keyboard", nil
+		}
+		return fmt.Sprintf("Push %q Kitty keyboard flag", flagDesc(first)), nil
+	case '<':
+		s.kittyFlags = s.kittyFlags[:max(0, len(s.kittyFlags)-default1(first))]
+		return fmt.Sprintf("Pop %d Kitty keyboard flags", first), nil
+	case '=':
+		if n, ok := p.Param(1, 0); ok {
+			if len(s.kittyFlags) == 0 {
+				s.kittyFlags = append(s.kittyFlags, 0)
+			}
+			top := &s.kittyFlags[len(s.kittyFlags)-1]
+			switch n {
+			case 1:
+				*top = first
+			case 2:
+				*top |= first
+			case 3:
+				*top &^= first
+			}
+			return fmt.Sprintf("Set %q Kitty keyboard flags to %q", flagDesc(first), modeDesc(n)), nil
+		}
+	}
+	return "", errUnhandled
+}
+
+//nolint:mnd
+var kittyModifiers = []string{"shift", "alt", "ctrl", "super", "hyper", "meta", "caps lock", "num lock"}
+
+// isKittyKey reports whether CSI u is a key event of the Kitty keyboard
+// protocol rather than SCORC: the protocol was negotiated, and the sequence
+// has the code of a key.
+func isKittyKey(s *session, p *ansi.Parser) bool {
+	return s.kittyKeyboard() && len(p.Params()) > 0
+}
+
+func handleKittyKey(_ *session, p *ansi.Parser) (string, error) {
+	return descKittyKey(p), nil
+}
+
+// descKittyKey describes a key event reported with the Kitty keyboard
+// protocol, i.e. CSI code[:alternates] ; modifiers[:event] ; text u.
+//
+//nolint:mnd
+func descKittyKey(p *ansi.Parser) string {
+	// Group parameters with their sub-parameters.
+	var groups [][]int
+	var group []int
+	for _, param := range p.Params() {
+		group = append(group, param.Param(0))
+		if !param.HasMore() {
+			groups = append(groups, group)
+			group = nil
+		}
+	}
+
+	code := groups[0][0]
+	var key string
+	switch code {
+	case 9:
+		key = "Tab"
+	case 13:
+		key = "Enter"
+	case 27:
+		key = "Escape"
+	case 127:
+		key = "Backspace"
+	default:
+		if code < 0xe000 || code > 0xf8ff {
+			key = fmt.Sprintf("%q", rune(code))
+		} else {
+			// Functional keys live in the Unicode private use area.
+			key = fmt.Sprintf("functional key %d", code)
+		}
+	}
+
+	desc := "Kitty key " + key
+	if len(groups) > 1 {
+		if mods := groups[1][0] - 1; mods > 0 {
+			var names []string
+			for i, name := range kittyModifiers {
+				if mods&(1<<i) != 0 {
+					names = append(names, name)
+				}
+			}
+			desc += " with " + strings.Join(names, "+")
+		}
+		if len(groups[1]) > 1 {
+			switch groups[1][1] {
+			case 1:
+				desc += " (press)"
+			case 2:
+				desc += " (repeat)"
+			case 3:
+				desc += " (release)"
+			}
+		}
+	}
+	return desc
+}
@@ -1,4 +1,4 @@
-package main
+package sequin
 
 import (
 	"fmt"
@@ -7,14 +7,27 @@ import (
 )
 
 //nolint:mnd
-func handleLine(p *ansi.Parser) (string, error) {
+func handleLine(_ *session, p *ansi.Parser) (string, error) {
 	var count int
 	if n, ok := p.Param(0, 0); ok {
 		count = n
 	}
 
-	switch p.Command() {
+	cmd := ansi.Cmd(p.Command())
+	switch cmd.Final() {
 	case 'K':
+		if cmd.Prefix() == '?' {
+			// DECSEL - Selective Erase in Line
+			switch count {
+			case 0:
+				return "Selectively erase line right", nil
+			case 1:
+				return "Selectively erase line left", nil
+			case 2:
+				return "Selectively erase entire line", nil
+			}
+			return "", errInvalid
+		}
 		switch count {
 		case 0:
 			return "Erase line right", nil
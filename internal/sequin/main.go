@@ -0,0 +1,480 @@
+// Package sequin explains ANSI escape sequences, for the sequin command line
+// and the tracing package.
+package sequin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+)
+
+const unknown = "Unknown"
+
+var (
+	raw     bool
+	strict  bool
+	offsets bool
+	hexdump bool
+)
+
+// Command returns the sequin command line.
+func Command() *cobra.Command {
+	return cmd()
+}
+
+func cmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "sequin",
+		Short: "Human-readable ANSI sequences",
+		Args:  cobra.ArbitraryArgs,
+		Example: `
+# Explain sequences from STDIN:
+printf '\x1b[m' | sequin
+
+# Explain sequences from a file:
+sequin <file
+
+# Explain escaped sequences from a log, or from the arguments:
+grep 'x1b' debug.log | sequin
+sequin -e '\e[?1049h' -e '\e[1;31m'
+
+# Explain a session recorded with script -B typescript -T timing:
+sequin --timing timing <typescript
+
+# Run a command and explain its output:
+sequin -- some command to execute
+
+# Drive a TUI with keys and a resize, and stop it after 5 seconds:
+sequin --send 'j j enter' --resize-at 2s:120x40 --timeout 5s -- some-tui
+
+# Run a command as it would in kitty, in a terminal of 100x30:
+sequin --profile kitty --cols 100 --rows 30 -- some-tui
+
+# See when each sequence arrived, and keep a recording of the output:
+sequin --timestamps rel --record out.cast -- some command to execute
+
+# Browse a long stream, with search and a preview of the screen:
+sequin -i <file
+
+# Only explain OSC sequences and mouse modes:
+sequin --only OSC --match 'mode.*mouse' <file
+	`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := colorprofile.NewWriter(cmd.OutOrStdout(), os.Environ())
+			if err := checkTimestamps(); err != nil {
+				return err
+			}
+			if timingFile != "" && len(args) > 0 {
+				return errTimingCommand
+			}
+			if len(sequences) > 0 && len(args) > 0 {
+				return errSequencesCommand
+			}
+			if teeFile != "" {
+				if len(args) == 0 {
+					return errTeeCommand
+				}
+				return tee(cmd.Context(), args)
+			}
+			// status is the exit status of a failed command, which sequin
+			// exits with.
+			var status *exec.ExitError
+			var in []byte
+			var rec recording
+			var err error
+			switch {
+			case len(sequences) > 0:
+				in = unescape([]byte(strings.Join(sequences, "")))
+			case len(args) == 0:
+				in, err = io.ReadAll(cmd.InOrStdin())
+				// Recordings are never guessed to be escaped: their
+				// text isn't, and a typescript's bytes are counted.
+				if unescapeInput || looksEscaped(in) && !isCast(in) && !isTtyrec(in) && timingFile == "" {
+					in = unescape(in)
+				}
+			default:
+				var c capture
+				c, err = execute(cmd.Context(), args)
+				if errors.As(err, &status) {
+					// The output is explained all the same.
+					err = nil
+				}
+				in = c.output()
+				// The output is what the command wrote, even if it looks
+				// like a recording.
+				rec = recording{chunks: []chunk{{data: in}}, width: c.width, height: c.height}
+				if c.timed {
+					rec.chunks, rec.start = c.chunks, c.start
+				}
+			}
+			if err != nil {
+				return err
+			}
+			switch {
+			case rec.chunks != nil:
+				// Read from the command.
+			case timingFile != "":
+				timing, err := os.ReadFile(timingFile)
+				if err != nil {
+					return err //nolint:wrapcheck
+				}
+				rec.chunks, err = readTypescript(in, timing)
+				if err != nil {
+					return err
+				}
+			default:
+				if rec, err = readChunks(in); err != nil {
+					return err
+				}
+			}
+			if interactive {
+				err = browse(cmd.OutOrStdout(), rec)
+			} else {
+				err = process(w, rec)
+			}
+			if err == nil && status != nil {
+				return status
+			}
+			return err
+		},
+	}
+	root.Flags().BoolVarP(&raw, "raw", "r", false, "raw mode (no explanation)")
+	root.Flags().BoolVarP(&interactive, "interactive", "i", false, "browse the sequences in a full-screen view")
+	root.Flags().BoolVar(&unescapeInput, "unescape", false, "read escaped sequences like \\x1b[1m or ^[[H (the default when the input has no ESC)")
+	root.Flags().StringArrayVarP(&sequences, "sequence", "e", nil, "explain this escaped sequence instead of reading the input")
+	root.Flags().StringVar(&timingFile, "timing", "", "read the input as a script(1) typescript, with this timing file from script -t or -T")
+	root.Flags().StringVar(&timestamps, "timestamps", "", "show when each sequence arrived: abs for the time of day, rel for the time since the start (recordings are always timed)")
+	root.Flags().StringVar(&recordFile, "record", "", "save the output of the command as an asciinema cast")
+	root.Flags().StringVar(&recordTiming, "record-timing", "", "with --record, save a script(1) typescript instead, with its timing in this file")
+	root.Flags().StringArrayVar(&sendKeys, "send", nil, "keys to send to the command, like 'j j ctrl+c', with text in quotes")
+	root.Flags().DurationVar(&sendDelay, "send-delay", 100*time.Millisecond, "time to wait before each key sent")
+	root.Flags().StringArrayVar(&resizeAt, "resize-at", nil, "resize the terminal of the command at a time, like 2s:120x40")
+	root.Flags().DurationVar(&execTimeout, "timeout", 0, "stop the command after this time")
+	root.Flags().BoolVar(&kittyKeys, "kitty-keys", false, "send keys with the Kitty keyboard protocol")
+	root.Flags().IntVar(&cols, "cols", 0, "width of the terminal of the command (default: the width of this one)")
+	root.Flags().IntVar(&rows, "rows", 0, "height of the terminal of the command (default: the height of this one)")
+	root.Flags().StringVar(&termName, "term", "", "TERM of the command; COLORTERM is set for -direct terminals, and unset otherwise")
+	root.Flags().StringArrayVar(&envVars, "env", nil, "set an environment variable of the command, as KEY=VALUE")
+	root.Flags().StringVar(&profileName, "profile", "", "mimic the environment of a terminal: "+strings.Join(profileNames(), ", "))
+	root.Flags().StringVar(&teeFile, "tee", "", "run the command on this terminal, and explain what it does in this file or named pipe as it happens")
+	root.Flags().BoolVar(&separateStderr, "stderr", false, "capture the stderr of the command apart, through a pipe")
+	root.Flags().BoolVar(&strict, "strict", false, "exit with an error if any sequence is unknown or invalid")
+	root.Flags().BoolVar(&offsets, "offsets", false, "prefix each sequence with its byte offset and length in the input")
+	root.Flags().BoolVar(&hexdump, "hex", false, "show a hexdump of the raw bytes of each sequence")
+	root.Flags().BoolVar(&mnemonics, "mnemonic", false, "show each sequence as its mnemonic and arguments, like SGR(bold, fg=red)")
+	root.PersistentFlags().StringSliceVar(&onlyKinds, "only", nil, "only show these kinds of sequences (e.g. CSI,OSC)")
+	root.PersistentFlags().StringSliceVar(&excludeKinds, "exclude", nil, "hide these kinds of sequences (e.g. Text,Ctrl)")
+	root.Flags().StringVar(&matchPattern, "match", "", "only show sequences whose explanation matches this pattern")
+	root.Flags().BoolVar(&frames, "frames", false, "split the output into frames, as drawn by TUIs")
+	root.Flags().BoolVar(&renderDiffs, "render", false, "with --frames, show the rows each frame changed on the screen")
+	root.Flags().BoolVar(&summary, "summary", false, "show summary statistics after the sequences")
+	root.PersistentFlags().IntVarP(&topN, "top", "n", defaultTopN, "number of most frequent sequences in the summary")
+	root.AddCommand(grepCmd(), statsCmd(), optimizeCmd(), diffCmd(), textconvCmd(), encodeCmd(), listCmd(), docCmd(), castCmd(), replayCmd())
+	return root
+}
+
+// newTheme returns the theme set by $SEQUIN_THEME, adapted to the
+// terminal background, and raw if --raw says so.
+func newTheme() theme {
+	t := themeFor(func() bool {
+		return lipgloss.HasDarkBackground(os.Stdin, os.Stdout)
+	})
+	t.IsRaw = raw
+	return t
+}
+
+// themeFor returns the theme set by $SEQUIN_THEME, for a background that is
+// dark when dark says so. It is only asked if the theme adapts.
+func themeFor(dark func() bool) theme {
+	var t theme
+	switch strings.ToLower(os.Getenv("SEQUIN_THEME")) {
+	case "ansi", "carlos", "secret_carlos", "matchy":
+		t = base16Theme(false)
+	default:
+		t = charmTheme(dark())
+	}
+
+	return t
+}
+
+func process(w *colorprofile.Writer, rec recording) error {
+	t := newTheme()
+	o := flagOptions(rec.start)
+	f, err := newFilter()
+	if err != nil {
+		return err
+	}
+
+	// problems counts the unknown, unrecognized, and invalid sequences.
+	var problems int
+	var s stats
+	var events []event
+
+	decodeChunks(rec.chunks, func(e event) {
+		if e.problem() {
+			problems++
+		}
+		if frames && (e.onScreen() || e.stream == streamResize) {
+			// Frames need all events to model the screen.
+			events = append(events, e)
+		}
+		if f.keep(e) {
+			if !frames {
+				printEvent(w, t, e, o)
+			}
+			s.add(e)
+		}
+	})
+
+	if frames {
+		printFrames(w, t, events, f, o, newGrid(rec.size()))
+	}
+
+	if summary {
+		if !raw {
+			_, _ = fmt.Fprintln(w)
+		}
+		s.print(w, t)
+	}
+
+	if strict && problems > 0 {
+		return fmt.Errorf("%w: %d unknown or invalid sequences", errStrict, problems)
+	}
+	return nil
+}
+
+// printOptions are what printEvent shows of the events.
+type printOptions struct {
+	raw       bool
+	offsets   bool
+	mnemonics bool
+	hexdump   bool
+
+	// start is when the stream started, to show the time of day of the
+	// events instead of the time since the start.
+	start time.Time
+}
+
+// flagOptions returns the print options set by the flags, for a stream
+// that started at start, if known.
+func flagOptions(start time.Time) printOptions {
+	o := printOptions{raw: raw, offsets: offsets, mnemonics: mnemonics, hexdump: hexdump}
+	if timestamps == "abs" {
+		o.start = start
+	}
+	return o
+}
+
+// printEvent writes the event and its explanation to w.
+func printEvent(w io.Writer, t theme, e event, o printOptions) {
+	if o.raw {
+		if !e.onScreen() {
+			// Only the output is shown as it was.
+			return
+		}
+		if e.kind == "Text" {
+			_, _ = fmt.Fprint(w, t.kindStyle("Text").Render(t.explanation.Render(string(e.seq))))
+			return
+		}
+		_, _ = fmt.Fprint(w, t.kindStyle(e.kind).Render(quote(e.seq)))
+		return
+	}
+
+	if e.stream != "" {
+		_, _ = fmt.Fprint(w, t.sequence.Render(fmt.Sprintf("%s %+8.3fs %-3s ", formatTime(e.at, o.start), e.gap.Seconds(), streamNames[e.stream])))
+	}
+	if o.offsets && e.stream != streamResize && e.stream != streamMarker && e.stream != streamExit {
+		_, _ = fmt.Fprint(w, t.sequence.Render(fmt.Sprintf("%08x+%-4d", e.offset, len(e.seq))))
+	}
+
+	if o.mnemonics {
+		_, _ = fmt.Fprintln(w, t.explanation.Render(e.mnemonic()))
+		if o.hexdump {
+			printHex(w, t, e)
+		}
+		return
+	}
+
+	s := seqString(e.seq)
+	switch e.kind {
+	case "Text":
+		var note string
+		if e.charset != "" {
+			note = t.explanation.Render(" (" + e.charset + ")")
+		}
+		_, _ = fmt.Fprintf(w, "%s%s%s\n", t.kindStyle("text"), t.text.Render(t.explanation.Render(e.desc)), note)
+
+	case "Ctrl":
+		if bytes.Equal(e.seq, []byte{ansi.ESC}) {
+			s = "ESC"
+		}
+		_, _ = fmt.Fprintf(
+			w,
+			"%s%s%s%s\n",
+			t.kindStyle(e.kind),
+			t.sequence.Render(s),
+			t.separator,
+			t.explanation.Render(e.desc),
+		)
+
+	case "Resize", "Marker", "Exit":
+		_, _ = fmt.Fprintln(w, t.explanation.Bold(true).Render(e.desc))
+		return
+
+	case "PM", "SOS":
+		_, _ = fmt.Fprintf(
+			w,
+			"%s%s%s\n",
+			t.kindStyle(e.kind),
+			t.separator,
+			t.explanation.Render(e.desc),
+		)
+
+	case "":
+		_, _ = fmt.Fprintf(
+			w,
+			"%s%s%s%s\n",
+			t.kindStyle(e.kind),
+			t.sequence.Render(s),
+			t.separator,
+			e.desc,
+		)
+
+	default:
+		_, _ = fmt.Fprintf(
+			w,
+			"%s%s%s",
+			t.kindStyle(e.kind),
+			t.sequence.Render(s),
+			t.separator,
+		)
+		switch {
+		case e.err != nil:
+			_, _ = fmt.Fprintln(w, t.error.Render(e.err.Error()))
+		case e.kind == "APC":
+			_, _ = fmt.Fprintln(w)
+		default:
+			_, _ = fmt.Fprintln(w, t.explanation.Render(e.desc))
+		}
+	}
+
+	if o.hexdump {
+		printHex(w, t, e)
+	}
+}
+
+// printHex writes the raw bytes of the event in the style of xxd, with
+// offsets relative to the start of the input.
+func printHex(w io.Writer, t theme, e event) {
+	for _, line := range hexLines(e, 16) { //nolint:mnd
+		_, _ = fmt.Fprintln(w, t.sequence.Render("     "+line))
+	}
+}
+
+// hexLines returns the raw bytes of the event in the style of xxd, with
+// width bytes per line.
+func hexLines(e event, width int) []string {
+	var lines []string
+	for i := 0; i < len(e.seq); i += width {
+		line := e.seq[i:min(i+width, len(e.seq))]
+		var hex strings.Builder
+		for j, b := range line {
+			if j > 0 && j%2 == 0 {
+				hex.WriteByte(' ')
+			}
+			_, _ = fmt.Fprintf(&hex, "%02x", b)
+		}
+		printable := bytes.Map(func(r rune) rune {
+			if r < ' ' || r > '~' {
+				return '.'
+			}
+			return r
+		}, line)
+		lines = append(lines, fmt.Sprintf("%08x: %-*s  %s", e.offset+i, width*5/2-1, hex.String(), printable)) //nolint:mnd
+	}
+	return lines
+}
+
+var ctrlCodes = map[byte]string{
+	// C0
+	0:  "Null",
+	1:  "Start of heading",
+	2:  "Start of text",
+	3:  "End of text",
+	4:  "End of transmission",
+	5:  "Enquiry",
+	6:  "Acknowledge",
+	7:  "Bell",
+	8:  "Backspace",
+	9:  "Horizontal tab",
+	10: "Line feed",
+	11: "Vertical tab",
+	12: "Form feed",
+	13: "Carriage return",
+	14: "Shift out",
+	15: "Shift in",
+	16: "Data link escape",
+	17: "Device control 1",
+	18: "Device control 2",
+	19: "Device control 3",
+	20: "Device control 4",
+	21: "Negative acknowledge",
+	22: "Synchronous idle",
+	23: "End of transmission block",
+	24: "Cancel",
+	25: "End of medium",
+	26: "Substitute",
+	27: "Escape",
+	28: "File separator",
+	29: "Group separator",
+	30: "Record separator",
+	31: "Unit separator",
+
+	// RFC 20, section 4.1 "Control Characters" includes DEL with the note:
+	// "In the strict sense, DEL is not a control character."
+	127: "Delete",
+
+	// C1
+	0x80: "Padding character",
+	0x81: "High octet preset",
+	0x82: "Break permitted here",
+	0x83: "No break here",
+	0x84: "Index",
+	0x85: "Next line",
+	0x86: "Start of selected area",
+	0x87: "End of selected area",
+	0x88: "Character tabulation set",
+	0x89: "Character tabulation with justification",
+	0x8a: "Line tabulation set",
+	0x8b: "Partial line forward",
+	0x8c: "Partial line backward",
+	0x8d: "Reverse line feed",
+	0x8e: "Single shift 2",
+	0x8f: "Single shift 3",
+	0x90: "Device control string",
+	0x91: "Private use 1",
+	0x92: "Private use 2",
+	0x93: "Set transmit state",
+	0x94: "Cancel character",
+	0x95: "Message waiting",
+	0x96: "Start of guarded area",
+	0x97: "End of guarded area",
+	0x98: "Start of string",
+	0x99: "Single graphic character introducer",
+	0x9a: "Single character introducer",
+	0x9b: "Control sequence introducer",
+	0x9c: "String terminator",
+	0x9d: "Operating system command",
+	0x9e: "Privacy message",
+	0x9f: "Application program command",
+}
@@ -0,0 +1,1168 @@
+package sequin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/exp/golden"
+	"github.com/stretchr/testify/require"
+)
+
+var c0c1 = map[string]string{
+	// c0
+	"c0": func() string {
+		var c0codes string
+		for controlCode := 0x00; controlCode <= 0x1f; controlCode++ {
+			c0codes = fmt.Sprintf("%s%c", c0codes, controlCode)
+		}
+		return c0codes
+	}(),
+	// c1
+	"c1": func() string {
+		var controlCode byte
+		c1codes := []byte("")
+		for controlCode = 0x80; controlCode <= 0x9f; controlCode++ {
+			// Skip DCS, SOS, CSI, OSC, PM, and APC
+			switch controlCode {
+			case ansi.DCS, ansi.SOS, ansi.CSI, ansi.OSC, ansi.PM, ansi.APC:
+				continue
+			}
+			c1codes = append(c1codes, controlCode)
+		}
+		return string(c1codes[:])
+	}(),
+}
+
+var ascii = map[string]string{
+	// space and del
+	"ascii": fmt.Sprintf(" %c", ansi.DEL),
+}
+
+var cursor = map[string]string{
+	// cursor
+	"save":                         ansi.SaveCursor,
+	"restore":                      ansi.RestoreCursor,
+	"request pos":                  ansi.RequestCursorPositionReport,
+	"request extended pos":         ansi.RequestExtendedCursorPositionReport,
+	"invalid request extended pos": strings.Replace(ansi.RequestExtendedCursorPositionReport, "6", "7", 1),
+	"up 1":                         ansi.CUU1,
+	"up":                           ansi.CursorUp(5),
+	"down 1":                       ansi.CUD1,
+	"down":                         ansi.CursorDown(3),
+	"right 1":                      ansi.CUF1,
+	"right":                        ansi.CursorForward(3),
+	"left 1":                       ansi.CUB1,
+	"left":                         ansi.CursorBackward(3),
+	"next line":                    ansi.CursorNextLine(3),
+	"previous line":                ansi.CursorPreviousLine(3),
+	"set pos":                      ansi.CursorPosition(10, 20),
+	"home pos":                     ansi.CursorHomePosition,
+	"save pos":                     ansi.SaveCurrentCursorPosition,
+	"restore pos":                  ansi.RestoreCurrentCursorPosition,
+	"style 0":                      ansi.SetCursorStyle(0),
+	"style 1":                      ansi.SetCursorStyle(1),
+	"style 2":                      ansi.SetCursorStyle(2),
+	"style 3":                      ansi.SetCursorStyle(3),
+	"style 4":                      ansi.SetCursorStyle(4),
+	"style 5":                      ansi.SetCursorStyle(5),
+	"style 6":                      ansi.SetCursorStyle(6),
+	"style 7":                      ansi.SetCursorStyle(7),
+	"pointer shape":                ansi.SetPointerShape("crosshair"),
+	"invalid pointer shape":        strings.Replace(ansi.SetPointerShape(""), ";", "", 1),
+	"left right margins":           ansi.SetModeLeftRightMargin + ansi.SetLeftRightMargins(5, 70),
+	"save pos with margins":        ansi.SetLeftRightMargins(5, 70),
+	"left margin only":             ansi.SetModeLeftRightMargin + "\x1b[5s",
+	"zero left margin":             ansi.SetModeLeftRightMargin + "\x1b[0;10s",
+}
+
+var screen = map[string]string{
+	"enable alt buffer":  ansi.SetModeAltScreenSaveCursor,
+	"disable alt buffer": ansi.ResetModeAltScreenSaveCursor,
+	"request alt buffer": ansi.RequestModeAltScreenSaveCursor,
+	"passthrough":        ansi.ScreenPassthrough(ansi.SaveCursor, 0), // TODO: impl
+	"erase above":        ansi.EraseScreenAbove,
+	"erase below":        ansi.EraseScreenBelow,
+	"erase full":         ansi.EraseEntireScreen,
+	"erase display":      ansi.EraseEntireDisplay,
+	"scrolling region":   ansi.SetTopBottomMargins(10, 20),
+	"selective erase":    "\x1b[?2J",
+	"invalid selective":  "\x1b[?5J",
+}
+
+var line = map[string]string{
+	"right":       ansi.EraseLineRight,
+	"left":        ansi.EraseLineLeft,
+	"entire":      ansi.EraseEntireLine,
+	"insert":      ansi.InsertLine(3),
+	"delete":      ansi.DeleteLine(5),
+	"scroll up":   ansi.ScrollUp(12),
+	"scroll down": ansi.ScrollDown(12),
+	"selective":   "\x1b[?1K",
+}
+
+var rect = map[string]string{
+	"copy":             "\x1b[1;2;3;4;1;5;6;2$v",
+	"fill":             "\x1b[65;2;2;10;20$x",
+	"erase":            "\x1b[$z",
+	"selective erase":  "\x1b[5;5;10${",
+	"change attrs":     "\x1b[1;1;5;5;1;4:3$r",
+	"reverse attrs":    "\x1b[1;1;5;5;7$t",
+	"extent stream":    "\x1b[*x",
+	"extent rectangle": "\x1b[2*x",
+	"invalid extent":   "\x1b[3*x",
+	"checksum":         "\x1b[7;1;1;1;5;5*y",
+	"protect":          "\x1b[1\"q",
+	"unprotect":        "\x1b[0\"q",
+}
+
+var mode = map[string]string{
+	"enable cursor keys":          ansi.SetModeCursorKeys,
+	"disable cursor keys":         ansi.ResetModeCursorKeys,
+	"request cursor keys":         ansi.RequestModeCursorKeys,
+	"enable cursor visibility":    ansi.SetModeTextCursorEnable,
+	"disable cursor visibility":   ansi.ResetModeTextCursorEnable,
+	"request cursor visibility":   ansi.RequestModeTextCursorEnable,
+	"enable mouse":                ansi.SetModeMouseNormal,
+	"disable mouse":               ansi.ResetModeMouseNormal,
+	"request mouse":               ansi.RequestModeMouseNormal,
+	"enable mouse hilite":         ansi.SetModeMouseHighlight,
+	"disable mouse hilite":        ansi.ResetModeMouseHighlight,
+	"request mouse hilite":        ansi.RequestModeMouseHighlight,
+	"enable mouse cellmotion":     ansi.SetModeMouseButtonEvent,
+	"disable mouse cellmotion":    ansi.ResetModeMouseButtonEvent,
+	"request mouse cellmotion":    ansi.RequestModeMouseButtonEvent,
+	"enable mouse allmotion":      ansi.SetModeMouseAnyEvent,
+	"disable mouse allmotion":     ansi.ResetModeMouseAnyEvent,
+	"request mouse allmotion":     ansi.RequestModeMouseAnyEvent,
+	"enable report focus":         ansi.SetModeFocusEvent,
+	"disable report focus":        ansi.ResetModeFocusEvent,
+	"request report focus":        ansi.RequestModeFocusEvent,
+	"enable mouse sgr":            ansi.SetModeMouseExtSgr,
+	"disable mouse sgr":           ansi.ResetModeMouseExtSgr,
+	"request mouse sgr":           ansi.RequestModeMouseExtSgr,
+	"enable altscreen":            ansi.SetModeAltScreenSaveCursor,
+	"disable altscreen":           ansi.ResetModeAltScreenSaveCursor,
+	"request altscreen":           ansi.RequestModeAltScreenSaveCursor,
+	"enable bracketed paste":      ansi.SetModeBracketedPaste,
+	"disable bracketed paste":     ansi.ResetModeBracketedPaste,
+	"request bracketed paste":     ansi.RequestModeBracketedPaste,
+	"enable synchronized output":  ansi.SetModeSynchronizedOutput,
+	"disable synchronized output": ansi.ResetModeSynchronizedOutput,
+	"request synchronized output": ansi.RequestModeSynchronizedOutput,
+	"enable grapheme clustering":  ansi.SetModeUnicodeCore,
+	"disable grapheme clustering": ansi.ResetModeUnicodeCore,
+	"request grapheme clustering": ansi.RequestModeUnicodeCore,
+	"enable win32 input":          ansi.SetModeWin32Input,
+	"disable win32 input":         ansi.ResetModeWin32Input,
+	"request win32 input":         ansi.RequestModeWin32Input,
+	"invalid":                     strings.Replace(ansi.SetModeTextCursorEnable, "25", "27", 1),
+	"non private":                 strings.Replace(ansi.SetModeTextCursorEnable, "?", "", 1),
+}
+
+var reset = map[string]string{
+	"soft reset":          "\x1b[!p",
+	"conformance vt100":   "\x1b[61\"p",
+	"conformance 7-bit":   "\x1b[64;1\"p",
+	"conformance 8-bit":   "\x1b[65\"p",
+	"invalid conformance": "\x1b[66\"p",
+}
+
+var xterm = map[string]string{
+	"set modify other keys":      ansi.SetModifyOtherKeys2,
+	"reset modify other keys":    ansi.ResetModifyOtherKeys,
+	"request modify other keys":  ansi.QueryModifyOtherKeys,
+	"invalid modify keys":        ansi.KeyModifierOptions(3, 1),
+	"push sgr":                   "\x1b[#{",
+	"push sgr attrs":             "\x1b[1;30;31#{",
+	"pop sgr":                    "\x1b[#}",
+	"report sgr":                 "\x1b[1;1;2;10#|",
+	"push colors":                "\x1b[#P",
+	"pop colors":                 "\x1b[3#Q",
+	"request graphics":           "\x1b[?1;1S",
+	"set sixel geometry":         "\x1b[?2;3;640;480S",
+	"invalid graphics":           "\x1b[?9;1S",
+	"set graphics without value": "\x1b[?1;3S",
+}
+
+var kitty = map[string]string{
+	"set all mode 1":   ansi.KittyKeyboard(ansi.KittyAllFlags, 1),
+	"set all mode 2":   ansi.KittyKeyboard(ansi.KittyAllFlags, 2),
+	"set all mode 3":   ansi.KittyKeyboard(ansi.KittyAllFlags, 3),
+	"set invalid mode": ansi.KittyKeyboard(ansi.KittyAllFlags, 4),
+	"request":          ansi.RequestKittyKeyboard,
+	"disable":          "\x1b[>0u",
+	"pop":              ansi.PopKittyKeyboard(2),
+	"push 1":           ansi.PushKittyKeyboard(1),
+	"push 2":           ansi.PushKittyKeyboard(2),
+	"push 4":           ansi.PushKittyKeyboard(4),
+	"push 8":           ansi.PushKittyKeyboard(8),
+	"push 16":          ansi.PushKittyKeyboard(16),
+	"key":              ansi.PushKittyKeyboard(1) + "\x1b[97;6:3u",
+	"pop restore":      ansi.PushKittyKeyboard(1) + ansi.PopKittyKeyboard(1) + "\x1b[97u",
+	"key with text":    ansi.PushKittyKeyboard(16) + "\x1b[97;2;65u",
+}
+
+var charset = map[string]string{
+	"line drawing": ansi.SelectCharacterSet('(', '0') + "lqqk" + ansi.SelectCharacterSet('(', 'B') + "ok",
+	"shift out":    ansi.SelectCharacterSet(')', '0') + "\x0ex\x0fx",
+	"uk":           ansi.SelectCharacterSet('*', 'A'),
+	"invalid":      ansi.SelectCharacterSet('(', '5'),
+}
+
+var others = map[string]string{
+	"request primary device attrs": ansi.RequestPrimaryDeviceAttributes,
+	"request xt version":           ansi.RequestNameVersion,
+	"termcap":                      ansi.RequestTermcap("bw", "ccc"),
+	"invalid termcap":              strings.Replace(ansi.RequestTermcap("a"), hex.EncodeToString([]byte("a")), "", 1),
+	"invalid termcap hex":          strings.Replace(ansi.RequestTermcap("a"), hex.EncodeToString([]byte("a")), "a", 1),
+	"invalid xt":                   "\x1b[>1q",
+	"unrecognized variant":         "\x1b[=1J",
+	"text":                         "some text",
+	"bold text":                    new(ansi.Style).Bold().String() + "some text" + ansi.ResetStyle,
+	"esc":                          fmt.Sprintf("%c", ansi.ESC),
+	"file sep":                     fmt.Sprintf("%c", ansi.FS),
+	"apc":                          "\x1b_Hello World\x1b\\",
+	"pm":                           "\x1b^Hello World\x1b\\",
+	"sos":                          "\x1bXHello World\x1b\\",
+}
+
+var sgr = map[string]string{
+	"reset":                        ansi.ResetStyle + strings.Replace(ansi.ResetStyle, "m", "0m", 1),
+	"style 1":                      new(ansi.Style).Bold().Faint().Italic(true).UnderlineStyle(ansi.UnderlineStyleCurly).String(),
+	"style 2":                      new(ansi.Style).Blink(true).Reverse(true).Strikethrough(true).String(),
+	"style 3":                      new(ansi.Style).RapidBlink(true).BackgroundColor(ansi.Green).ForegroundColor(ansi.BrightGreen).UnderlineColor(ansi.Blue).String(),
+	"style 4":                      new(ansi.Style).BackgroundColor(ansi.BrightYellow).ForegroundColor(ansi.Black).UnderlineColor(ansi.BrightCyan).String(),
+	"style 5":                      new(ansi.Style).BackgroundColor(ansi.RGBColor{R: 0xff, G: 0xee, B: 0xaa}).ForegroundColor(ansi.RGBColor{R: 0xff, G: 0xee, B: 0xaa}).UnderlineColor(ansi.RGBColor{R: 0xff, G: 0xee, B: 0xaa}).String(),
+	"style 6":                      new(ansi.Style).BackgroundColor(ansi.IndexedColor(255)).ForegroundColor(ansi.IndexedColor(255)).UnderlineColor(ansi.IndexedColor(255)).String(),
+	"style 7":                      new(ansi.Style).Underline(false).Italic(false).Normal().Blink(false).Conceal(false).Reverse(false).Strikethrough(false).String(),
+	"style 8":                      new(ansi.Style).UnderlineStyle(ansi.UnderlineStyleNone).BackgroundColor(nil).String(),
+	"style 9":                      strings.Replace(new(ansi.Style).UnderlineStyle(ansi.UnderlineStyleSingle).ForegroundColor(nil).String(), "[4", "[4:1", 1),
+	"style 10":                     new(ansi.Style).UnderlineStyle(ansi.UnderlineStyleDouble).String(),
+	"style 11":                     new(ansi.Style).UnderlineStyle(ansi.UnderlineStyleCurly).String(),
+	"style 12":                     new(ansi.Style).UnderlineStyle(ansi.UnderlineStyleDotted).String(),
+	"style 13":                     new(ansi.Style).UnderlineStyle(ansi.UnderlineStyleDashed).Conceal(true).String(),
+	"empty values":                 strings.Replace(new(ansi.Style).Bold().String(), "[", "[;;;", 1),
+	"underlined text, but no bold": new(ansi.Style).UnderlineStyle(ansi.UnderlineStyleCurly).Bold().String(),
+	"mittchels tweet":              "\033[;4:3;38;2;175;175;215;58:2::190:80:70m",
+}
+
+var title = map[string]string{
+	"set":         ansi.SetWindowTitle("hello"),
+	"set icon":    ansi.SetIconName("terminal"),
+	"set both":    ansi.SetIconNameWindowTitle("terminal"),
+	"invalid":     strings.Replace(ansi.SetWindowTitle("hello"), ";hello", "", 1),
+	"invalid cmd": strings.Replace(ansi.SetWindowTitle("hello"), "2", "5", 1),
+}
+
+var cwd = map[string]string{
+	"single part":    ansi.NotifyWorkingDirectory("localhost", "foo"),
+	"multiple parts": ansi.NotifyWorkingDirectory("localhost", "foo", "bar"),
+	"invalid":        strings.Replace(ansi.NotifyWorkingDirectory("localhost", "foo"), ";", "", 1),
+	"invalid url":    strings.Replace(ansi.NotifyWorkingDirectory("localhost", "foo"), "file://localhost/foo", "foooooo:/bar", 1),
+}
+
+var hyperlink = map[string]string{
+	"uri only":        ansi.SetHyperlink("https://charm.sh"),
+	"full":            ansi.SetHyperlink("https://charm.sh", "my title"),
+	"reset":           ansi.ResetHyperlink("my title"),
+	"multiple params": ansi.SetHyperlink("https://charm.sh", "my title", "some description"),
+	"invalid":         strings.Replace(ansi.ResetHyperlink(), ";", "", 1),
+}
+
+var notify = map[string]string{
+	"notify":  ansi.Notify("notification body"),
+	"invalid": strings.Replace(ansi.Notify(""), ";", "", 1),
+}
+
+var termcolor = map[string]string{
+	"set bg":         ansi.SetBackgroundColor("#000000"),
+	"set fg":         ansi.SetForegroundColor("#800000"),
+	"set cursor":     ansi.SetCursorColor("#000080"),
+	"request bg":     ansi.RequestBackgroundColor,
+	"request fg":     ansi.RequestForegroundColor,
+	"request cursor": ansi.RequestCursorColor,
+	"reset bg":       ansi.ResetBackgroundColor,
+	"reset fg":       ansi.ResetForegroundColor,
+	"reset cursor":   ansi.ResetCursorColor,
+	"invalid set":    strings.Replace(ansi.SetBackgroundColor("#000000"), ";", "", 1),
+	"invalid reset":  strings.Replace(ansi.ResetBackgroundColor, "111", "111;1", 1),
+}
+
+var clipboard = map[string]string{
+	"request system":  ansi.RequestSystemClipboard,
+	"request primary": ansi.RequestPrimaryClipboard,
+	"set system":      ansi.SetSystemClipboard("hello"),
+	"set primary":     ansi.SetPrimaryClipboard("hello"),
+	"incomplete":      strings.Replace(ansi.RequestPrimaryClipboard, ";?", "", 1),
+	"invalid":         strings.Replace(ansi.SetPrimaryClipboard("hello"), "=", "", 1),
+}
+
+var finalterm = map[string]string{
+	"prompt start":               ansi.FinalTermPrompt(),
+	"prompt start invalid":       ansi.FinalTerm("AB"),
+	"command start":              ansi.FinalTermCmdStart(),
+	"command executed":           ansi.FinalTermCmdExecuted(),
+	"command finished":           ansi.FinalTermCmdFinished(),
+	"command finished exit code": ansi.FinalTermCmdFinished("127"),
+	"invalid":                    ansi.FinalTerm("Q"),
+}
+
+var keypad = map[string]string{
+	"normal keypad":      ansi.KeypadNumericMode,
+	"application keypad": ansi.KeypadApplicationMode,
+}
+
+func TestSequences(t *testing.T) {
+	for name, table := range map[string]map[string]string{
+		"c0c1":      c0c1,
+		"ascii":     ascii,
+		"cursor":    cursor,
+		"screen":    screen,
+		"line":      line,
+		"rect":      rect,
+		"mode":      mode,
+		"kitty":     kitty,
+		"charset":   charset,
+		"reset":     reset,
+		"xterm":     xterm,
+		"sgr":       sgr,
+		"title":     title,
+		"cwd":       cwd,
+		"hyperlink": hyperlink,
+		"notify":    notify,
+		"termcolor": termcolor,
+		"clipboard": clipboard,
+		"others":    others,
+		"finalterm": finalterm,
+		"keypad":    keypad,
+	} {
+		t.Run(name, func(t *testing.T) {
+			for name, input := range table {
+				t.Run(name, func(t *testing.T) {
+					var b bytes.Buffer
+					cmd := cmd()
+					cmd.SetOut(&b)
+					cmd.SetErr(&b)
+					cmd.SetIn(strings.NewReader(input))
+					cmd.SetArgs([]string{})
+					require.NoError(t, cmd.Execute())
+					golden.RequireEqual(t, b.Bytes())
+				})
+			}
+		})
+	}
+}
+
+func TestStrict(t *testing.T) {
+	for name, tc := range map[string]struct {
+		input string
+		fails bool
+	}{
+		"known":     {ansi.EraseEntireScreen, false},
+		"unhandled": {"\x1b[1Z", true},
+		"variant":   {"\x1b[=1J", true},
+		"invalid":   {strings.Replace(ansi.SetWindowTitle("hello"), ";hello", "", 1), true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			cmd := cmd()
+			cmd.SetOut(&b)
+			cmd.SetErr(&b)
+			cmd.SetIn(strings.NewReader(tc.input))
+			cmd.SetArgs([]string{"--strict"})
+			err := cmd.Execute()
+			if tc.fails {
+				require.ErrorIs(t, err, errStrict)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTypescript(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, s string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(s), 0o600))
+		return path
+	}
+
+	for name, tc := range map[string]struct {
+		typescript, timing string
+	}{
+		"classic": {
+			typescript: "Script started on 2025-01-01 12:00:00+00:00 [TERM=\"xterm\"]\n" +
+				"\x1b[?1049h\x1b[1;31mhello\x1b[m\x1b[?1049l",
+			timing: "0.100000 8\n0.250000 15\n1.000000 8\n",
+		},
+		"advanced": {
+			typescript: "\x1b[?1049hq\x1b[?1049l",
+			timing: "H 0.000000 START_TIME 2025-01-01 12:00:00\n" +
+				"O 0.100000 8\n" +
+				"S 0.200000 SIGWINCH ROWS=40 COLS=120\n" +
+				"I 0.400000 1\n" +
+				"O 0.050000 8\n",
+		},
+		// Escaped text in a typescript is text: unescaping it would
+		// shift the bytes the timing counts.
+		"escaped text": {
+			typescript: `$ printf '\e[1m'` + "\r\n",
+			timing:     "0.100000 9\n0.200000 9\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			cmd := cmd()
+			cmd.SetOut(&b)
+			cmd.SetErr(&b)
+			cmd.SetIn(strings.NewReader(tc.typescript))
+			cmd.SetArgs([]string{"--timing", write(name, tc.timing)})
+			require.NoError(t, cmd.Execute())
+			golden.RequireEqual(t, b.Bytes())
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	c := capture{
+		args:   []string{"printf", "\\e[1mhi"},
+		start:  time.Unix(1700000000, 0),
+		width:  80,
+		height: 24,
+		chunks: []chunk{
+			{stream: streamOutput, at: 100 * time.Millisecond, data: []byte("\x1b[1mh\xc3")},
+			{stream: streamOutput, at: 400 * time.Millisecond, data: []byte("\xa9\x1b[m")},
+		},
+	}
+
+	t.Run("cast", func(t *testing.T) {
+		var b bytes.Buffer
+		require.NoError(t, writeCast(&b, c))
+		golden.RequireEqual(t, b.Bytes())
+
+		// A rune split between reads is written with the second one.
+		got, err := readCast(b.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, c.output(), capture{chunks: got.chunks}.output())
+		require.Equal(t, c.start, got.start)
+	})
+
+	t.Run("exit", func(t *testing.T) {
+		// Version 2 casts have no exit events.
+		c := capture{chunks: []chunk{
+			{stream: streamOutput, at: time.Second, data: []byte("hi")},
+			{stream: streamExit, at: 2 * time.Second, data: []byte("0")},
+		}}
+		var b bytes.Buffer
+		require.NoError(t, writeCast(&b, c))
+		require.NotContains(t, b.String(), `"x"`)
+	})
+
+	t.Run("cut short", func(t *testing.T) {
+		// A rune the output ends in the middle of is still written, as
+		// well as JSON can.
+		c := capture{chunks: []chunk{{stream: streamOutput, at: time.Second, data: []byte("hi\xe2\x94")}}}
+		var b bytes.Buffer
+		require.NoError(t, writeCast(&b, c))
+		got, err := readCast(b.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, []chunk{
+			{stream: streamOutput, at: time.Second, data: []byte("hi")},
+			{stream: streamOutput, at: time.Second, data: []byte("��")},
+		}, got.chunks)
+	})
+
+	t.Run("typescript", func(t *testing.T) {
+		var b, timing bytes.Buffer
+		require.NoError(t, writeTypescript(&b, &timing, c))
+		got, err := readTypescript(b.Bytes(), timing.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, c.chunks, got)
+	})
+}
+
+func TestReplay(t *testing.T) {
+	in := ansi.CursorHomePosition + ansi.EraseEntireScreen + "hello" +
+		ansi.CursorHomePosition + new(ansi.Style).Bold().String() + "world" + ansi.ResetStyle +
+		ansi.CursorPosition(1, 2) + "!"
+	var events []event
+	decode([]byte(in), func(e event) { events = append(events, e) })
+
+	var b bytes.Buffer
+	r := newReplayer(events, 40, 5)
+	require.NoError(t, r.run(strings.NewReader(" f/Bold\r/nope\x7f\x7f\x7f\x7fCUP\rnq"), &b))
+	require.Equal(t, 9, r.pos)
+	golden.RequireEqual(t, b.Bytes())
+
+	t.Run("replies", func(t *testing.T) {
+		// The replies to DA1, DSR, DECRQM, and OSC 11 aren't keys, though
+		// DA1 ends with c.
+		r := newReplayer(events, 40, 5)
+		keys := keyReader{bufio.NewReader(strings.NewReader("\x1b[?62;22c\x1b[3;1R\x1b[?2026;2$y" +
+			"\x1b]11;rgb:0000/0000/0000\x1b\\\x1bOP j\x1b[?1;2cq"))}
+		require.NoError(t, r.run(keys, io.Discard))
+		require.Equal(t, 2, r.pos)
+	})
+}
+
+func TestBrowser(t *testing.T) {
+	in := ansi.CursorHomePosition + ansi.EraseEntireScreen + "hello" +
+		ansi.CursorPosition(1, 2) + new(ansi.Style).Bold().String() + "world" + ansi.ResetStyle +
+		ansi.SetWindowTitle("title")
+	var events []event
+	decode([]byte(in), func(e event) { events = append(events, e) })
+
+	key := func(s string) tea.KeyPressMsg {
+		r := []rune(s)
+		return tea.KeyPressMsg{Code: r[0], Text: s}
+	}
+	b := newBrowser(newTheme(), events)
+	b.Update(tea.WindowSizeMsg{Width: 110, Height: 16})
+	// The steps run in order, on the same browser.
+	for _, step := range []struct {
+		name string
+		msgs []tea.Msg
+	}{
+		{"start", nil},
+		{"move", []tea.Msg{key("j"), key("j"), key("j")}},
+		{"search", []tea.Msg{key("/"), key("b"), key("o"), key("l"), key("d"), tea.KeyPressMsg{Code: tea.KeyEnter}}},
+		{"hide", []tea.Msg{key("1"), key("2")}},
+		{"next", []tea.Msg{key("/"), key("t"), key("i"), key("t"), tea.KeyPressMsg{Code: tea.KeyEnter}, key("n")}},
+	} {
+		t.Run(step.name, func(t *testing.T) {
+			for _, msg := range step.msgs {
+				b.Update(msg)
+			}
+			golden.RequireEqual(t, []byte(ansi.Strip(b.View().Content)))
+		})
+	}
+
+	t.Run("match", func(t *testing.T) {
+		matchPattern, onlyKinds = "Bold|title", []string{"CSI", "OSC"}
+		defer func() { matchPattern, onlyKinds = "", nil }()
+		f, err := newFilter()
+		require.NoError(t, err)
+		b := newBrowser(newTheme(), events)
+		b.apply(f)
+		var got []string
+		for _, i := range b.visible {
+			got = append(got, events[i].name)
+		}
+		require.Equal(t, []string{"SGR", "OSC2"}, got)
+		require.True(t, b.hidden["Text"])
+		require.False(t, b.hidden["CSI"])
+	})
+}
+
+func TestKeys(t *testing.T) {
+	for name, tc := range map[string]struct {
+		keys  string
+		kitty bool
+		want  []string
+	}{
+		"text":           {keys: `j k "hello world"`, want: []string{"j", "k", "hello world"}},
+		"named":          {keys: "enter tab esc backspace space", want: []string{"\r", "\t", "\x1b", "\x7f", " "}},
+		"ctrl":           {keys: "ctrl+c ctrl+[ ctrl+space", want: []string{"\x03", "\x1b", "\x00"}},
+		"alt shift":      {keys: "alt+x shift+a alt+shift+tab", want: []string{"\x1bx", "A", "\x1b\x1b[Z"}},
+		"arrows":         {keys: "up ctrl+left shift+alt+end", want: []string{"\x1b[A", "\x1b[1;5D", "\x1b[1;4F"}},
+		"functions":      {keys: "f1 f3 f5 ctrl+f12 pgdown", want: []string{"\x1bOP", "\x1bOR", "\x1b[15~", "\x1b[24;5~", "\x1b[6~"}},
+		"kitty":          {keys: "a shift+a ctrl+i tab esc shift+enter", kitty: true, want: []string{"a", "A", "\x1b[105;5u", "\t", "\x1b[27u", "\x1b[13;2u"}},
+		"kitty function": {keys: "f1 f3 ctrl+up super+k", kitty: true, want: []string{"\x1b[P", "\x1b[13~", "\x1b[1;5A", "\x1b[107;9u"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseKeys(tc.keys, tc.kitty)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	for _, keys := range []string{"hello", "ctrl+enter", "hyper+a", "super+a", `"unterminated`} {
+		t.Run(keys, func(t *testing.T) {
+			_, err := parseKeys(keys, false)
+			require.ErrorIs(t, err, errKey)
+		})
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	base := []string{"HOME=/home/me", "TERM=xterm-256color", "COLORTERM=truecolor", "TERM_PROGRAM=ghostty", "KITTY_WINDOW_ID=3"}
+	for name, tc := range map[string]struct {
+		profile, term string
+		env           []string
+		want          []string
+	}{
+		"unchanged": {want: base},
+		"profile": {
+			profile: "WezTerm",
+			want:    []string{"HOME=/home/me", "TERM=xterm-256color", "COLORTERM=truecolor", "TERM_PROGRAM=WezTerm", "WEZTERM_PANE=0"},
+		},
+		"term": {
+			term: "xterm-direct",
+			want: []string{"HOME=/home/me", "TERM_PROGRAM=ghostty", "KITTY_WINDOW_ID=3", "TERM=xterm-direct", "COLORTERM=truecolor"},
+		},
+		"term without truecolor": {
+			term: "linux",
+			want: []string{"HOME=/home/me", "TERM_PROGRAM=ghostty", "KITTY_WINDOW_ID=3", "TERM=linux"},
+		},
+		"env": {
+			profile: "dumb",
+			env:     []string{"HOME=/tmp", "NO_COLOR=1"},
+			want:    []string{"TERM=dumb", "HOME=/tmp", "NO_COLOR=1"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			profileName, termName, envVars = tc.profile, tc.term, tc.env
+			t.Cleanup(func() { profileName, termName, envVars = "", "", nil })
+			got, err := environ(base)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	for name, set := range map[string]func(){
+		"unknown profile": func() { profileName = "nope" },
+		"bad env":         func() { envVars = []string{"NOPE"} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			set()
+			t.Cleanup(func() { profileName, envVars = "", nil })
+			_, err := environ(base)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	streams := func(c capture) map[string]string {
+		m := map[string]string{}
+		for _, ch := range c.chunks {
+			m[ch.stream] += string(ch.data)
+		}
+		return m
+	}
+	tty := terminal{width: 80, height: 24, env: os.Environ()}
+
+	t.Run("drain", func(t *testing.T) {
+		c, err := executeCommand(t.Context(), []string{"sh", "-c", "(sleep 0.2; echo late) & echo early"}, tty, script{})
+		require.NoError(t, err)
+		require.Equal(t, "early\r\nlate\r\n", string(c.output()))
+	})
+
+	t.Run("exit status", func(t *testing.T) {
+		c, err := executeCommand(t.Context(), []string{"sh", "-c", "printf out; exit 3"}, tty, script{})
+		var status *exec.ExitError
+		require.ErrorAs(t, err, &status)
+		require.Equal(t, 3, status.ExitCode())
+		require.Equal(t, map[string]string{streamOutput: "out", streamExit: "3"}, streams(c))
+	})
+
+	t.Run("stderr", func(t *testing.T) {
+		tty := tty
+		tty.stderr = true
+		c, err := executeCommand(t.Context(), []string{"sh", "-c", "echo out; echo err >&2"}, tty, script{})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{streamOutput: "out\r\n", streamError: "err\n", streamExit: "0"}, streams(c))
+	})
+
+	t.Run("script", func(t *testing.T) {
+		s := script{keys: []string{"hi\r"}, delay: 200 * time.Millisecond, timeout: 500 * time.Millisecond}
+		c, err := executeCommand(t.Context(), []string{"sh", "-c", "stty -echo; read a; echo got $a; sleep 5"}, tty, s)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			streamInput:  "hi\r",
+			streamOutput: "got hi\r\n",
+			streamMarker: "timeout after 500ms",
+		}, streams(c))
+	})
+
+	t.Run("output is not a recording", func(t *testing.T) {
+		// What a command writes is explained as is, even when it looks
+		// like a recording.
+		var b bytes.Buffer
+		cmd := cmd()
+		cmd.SetOut(&b)
+		cmd.SetErr(&b)
+		cmd.SetArgs([]string{"--", "printf", `{"version": 2}\n[0.1, "o", "\033[1m"]\n`})
+		require.NoError(t, cmd.Execute())
+		require.Contains(t, b.String(), `"version"`)
+		require.NotContains(t, b.String(), "bold")
+	})
+
+	t.Run("timing", func(t *testing.T) {
+		cmd := cmd()
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{"--timing", "timing.log", "--", "true"})
+		cmd.SilenceErrors, cmd.SilenceUsage = true, true
+		require.ErrorIs(t, cmd.Execute(), errTimingCommand)
+	})
+
+	t.Run("sequences", func(t *testing.T) {
+		cmd := cmd()
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{"-e", `\e[1m`, "--", "true"})
+		cmd.SilenceErrors, cmd.SilenceUsage = true, true
+		require.ErrorIs(t, cmd.Execute(), errSequencesCommand)
+	})
+}
+
+func TestGridScroll(t *testing.T) {
+	// Counts from the stream are clamped to the scrolling region.
+	for _, final := range []string{"S", "T", "L", "M"} {
+		t.Run(final, func(t *testing.T) {
+			g := newGrid(10, 3)
+			_, _ = g.Write([]byte("a\r\nb\r\nc" + ansi.CursorHomePosition + "\x1b[999999999" + final + "d"))
+			require.Equal(t, "d", g.String())
+		})
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	for name, tc := range map[string]struct{ in, want string }{
+		"placed": {"\x1b[Hhi\x1b[1;3Hx", "\x1b[Hhix"},
+		// A bare SGR resets the style, so bold is set again.
+		"reset": {"\x1b[H\x1b[1mA\x1b[mB\x1b[1mC", "\x1b[H\x1b[1mA\x1b[mB\x1b[1mC"},
+		// The style the stream starts with is unknown.
+		"start": {"\x1b[mA", "\x1b[mA"},
+		// Attributes the pen doesn't know are only dropped by a reset.
+		"unknown": {"\x1b[m\x1b[53mA\x1b[m", "\x1b[m\x1b[53mA\x1b[m"},
+		// Where the cursor is isn't known before the first CUP, after
+		// reaching the edge of the screen, or with DECOM or DECLRMM set.
+		"unplaced": {"hi\x1b[1;3Hx", "hi\x1b[1;3Hx"},
+		"edge":     {"\x1b[H" + strings.Repeat("x", defaultWidth) + "\x1b[2;1H", "\x1b[H" + strings.Repeat("x", defaultWidth) + "\x1b[2H"},
+		"origin":   {"\x1b[H\x1b[?6h\x1b[1;1H", "\x1b[H\x1b[?6h\x1b[H"},
+		"margins":  {"\x1b[H\x1b[?69h\x1b[1;1H", "\x1b[H\x1b[?69h\x1b[H"},
+		"spaces":   {"\x1b[m\x1b[Ha            \x1b[1m", "\x1b[m\x1b[Ha\x1b[12X\x1b[12C\x1b[1m"},
+		// Spaces are only erased where the cursor is known not to wrap.
+		"unplaced spaces": {"\x1b[ma            \x1b[1m", "\x1b[ma            \x1b[1m"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := applyFindings([]byte(tc.in), optimize([]byte(tc.in), 0, 0))
+			require.Equal(t, tc.want, string(got))
+		})
+	}
+
+	t.Run("sized", func(t *testing.T) {
+		// The cursor stops at the edge of a screen of a known size.
+		in := "\x1b[H\x1b[200C\x1b[1;80H"
+		require.Equal(t, in, string(applyFindings([]byte(in), optimize([]byte(in), 0, 0))))
+		require.Equal(t, "\x1b[H\x1b[200C", string(applyFindings([]byte(in), optimize([]byte(in), 80, 24))))
+	})
+}
+
+func TestGridResize(t *testing.T) {
+	chunks := []chunk{
+		{stream: streamOutput, data: []byte("\x1b[1;90Hwide\x1b[3;1Hbottom")},
+		{stream: streamResize, data: []byte("120x2")},
+		{stream: streamOutput, data: []byte("\r\nmore")},
+	}
+	g := render(recording{width: 100, height: 3, chunks: chunks[:1]})
+	require.Equal(t, []string{strings.Repeat(" ", 89) + "wide", "", "bottom"}, g.lines())
+	// Rows are cut off the top to keep the cursor on the screen.
+	g = render(recording{width: 100, height: 3, chunks: chunks})
+	require.Equal(t, []string{"bottom", "more"}, g.lines())
+
+	// Without a size, the default one is used.
+	require.Equal(t, defaultHeight, len(render(recording{chunks: []chunk{{data: []byte("hi")}}}).lines()))
+}
+
+func TestTracer(t *testing.T) {
+	in := "a\x1b[1mé\x1b(0q\x1b(B\x1b]0;title\x07b\x1b[?25"
+	describe := func(e event) string {
+		return fmt.Sprintf("%d %s %s", e.offset, e.kind, e.desc)
+	}
+	var want []string
+	decode([]byte(in), func(e event) {
+		want = append(want, describe(e))
+	})
+
+	// Written a byte at a time, split sequences and characters are held
+	// until they're whole, or the stream ends.
+	var got []string
+	tr := newTracer(newTraceLog(func(e event) {
+		require.Equal(t, streamOutput, e.stream)
+		got = append(got, describe(e))
+	}), streamOutput)
+	for i := range len(in) {
+		n, err := tr.Write([]byte{in[i]})
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+	}
+	require.Equal(t, want[:len(want)-1], got)
+	tr.Flush()
+	require.Equal(t, want, got)
+
+	// Nothing is explained once the log is closed.
+	tr.log.close()
+	_, err := tr.Write([]byte("late"))
+	require.NoError(t, err)
+	tr.Flush()
+	require.Equal(t, want, got)
+}
+
+func TestTtyrec(t *testing.T) {
+	var in []byte
+	for _, frame := range []struct {
+		sec, usec uint32
+		data      string
+	}{
+		{1700000000, 900000, "\x1b[?1049h\x1b[1;"},
+		{1700000001, 100000, "31mhello\x1b[m"},
+		{1700000002, 0, "\x1b[?1049l"},
+	} {
+		in = binary.LittleEndian.AppendUint32(in, frame.sec)
+		in = binary.LittleEndian.AppendUint32(in, frame.usec)
+		in = binary.LittleEndian.AppendUint32(in, uint32(len(frame.data)))
+		in = append(in, frame.data...)
+	}
+
+	var b bytes.Buffer
+	cmd := cmd()
+	cmd.SetOut(&b)
+	cmd.SetErr(&b)
+	cmd.SetIn(bytes.NewReader(in))
+	cmd.SetArgs([]string{})
+	require.NoError(t, cmd.Execute())
+	golden.RequireEqual(t, b.Bytes())
+}
+
+func TestDiff(t *testing.T) {
+	a := ansi.SetModeAltScreenSaveCursor + ansi.CursorHomePosition +
+		new(ansi.Style).Bold().ForegroundColor(ansi.Red).String() + "hello" + ansi.ResetStyle +
+		"\r\nworld"
+	b := ansi.SetModeAltScreenSaveCursor + ansi.CursorHomePosition + ansi.HideCursor +
+		new(ansi.Style).Bold().ForegroundColor(ansi.TrueColor(0xff0000)).String() + "hello" + "\x1b[0m" +
+		"\r\nthere"
+
+	dir := t.TempDir()
+	write := func(name, s string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(s), 0o600))
+		return path
+	}
+	pa, pb := write("a", a), write("b", b)
+	same := write("same", strings.Replace(a, ansi.ResetStyle, "\x1b[0m", 1))
+
+	for name, tc := range map[string]struct {
+		args   []string
+		differ bool
+	}{
+		"events":       {args: []string{"diff", pa, pb}, differ: true},
+		"screen":       {args: []string{"diff", "--screen", pa, pb}, differ: true},
+		"same screen":  {args: []string{"diff", "--screen", pa, same}},
+		"same streams": {args: []string{"diff", pa, pa}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			cmd := cmd()
+			cmd.SetOut(&b)
+			cmd.SetErr(&b)
+			cmd.SetArgs(tc.args)
+			cmd.SilenceErrors, cmd.SilenceUsage = true, true
+			err := cmd.Execute()
+			if tc.differ {
+				require.ErrorIs(t, err, errDiffer)
+			} else {
+				require.NoError(t, err)
+			}
+			golden.RequireEqual(t, b.Bytes())
+		})
+	}
+}
+
+func TestAlign(t *testing.T) {
+	events := func(s string) []event {
+		var es []event
+		for _, c := range s {
+			es = append(es, event{seq: []byte(string(c))})
+		}
+		return es
+	}
+	// script writes the alignment as matches, removals, and additions.
+	script := func(ops []alignment) string {
+		var s strings.Builder
+		for _, op := range ops {
+			switch {
+			case op.a != nil && op.b != nil:
+				s.WriteString(string(op.a.seq))
+			case op.a != nil:
+				s.WriteString("-" + string(op.a.seq))
+			default:
+				s.WriteString("+" + string(op.b.seq))
+			}
+		}
+		return s.String()
+	}
+
+	for a, want := range map[string]string{
+		"abcabba": "-a-bc+bab-ba+c", // 5 edits, as in Myers' paper
+		"":        "+c+b+a+b+a+c",
+		"cbabac":  "cbabac",
+	} {
+		ops, ok := align(events(a), events("cbabac"))
+		require.True(t, ok)
+		require.Equal(t, want, script(ops), a)
+	}
+
+	t.Run("too many edits", func(t *testing.T) {
+		a := "x" + strings.Repeat("a", maxEdits) + "y"
+		b := "x" + strings.Repeat("b", maxEdits) + "y"
+		ops, ok := align(events(a), events(b))
+		require.False(t, ok)
+		require.Equal(t, "x"+strings.Repeat("-a", maxEdits)+strings.Repeat("+b", maxEdits)+"y", script(ops))
+	})
+
+	t.Run("large streams", func(t *testing.T) {
+		// Streams too different to align are still compared quickly.
+		const n = 50000
+		a := strings.Repeat(ansi.CursorUp(2), n)
+		b := strings.Repeat(ansi.CursorDown(2), n)
+		var out bytes.Buffer
+		start := time.Now()
+		require.Equal(t, 2*n, diffStreams(&out, newTheme(), []byte(a), []byte(b)))
+		require.Less(t, time.Since(start), 10*time.Second)
+		require.Contains(t, out.String(), fmt.Sprintf("%d removed, %d added, 0 changed", n, n))
+	})
+}
+
+func TestEncode(t *testing.T) {
+	for name, tc := range map[string]struct {
+		src  string
+		want string
+	}{
+		"modes":      {"DECSET 1006 2004; DECRST 25", ansi.SetModeMouseExtSgr[:len(ansi.SetModeMouseExtSgr)-1] + ";2004h" + ansi.HideCursor},
+		"style":      {"SGR bold fg=red bg=#ff8800 ul=200 underline=curly", new(ansi.Style).Bold().ForegroundColor(ansi.Red).BackgroundColor(ansi.TrueColor(0xff8800)).UnderlineColor(ansi.IndexedColor(200)).UnderlineStyle(ansi.CurlyUnderlineStyle).String()},
+		"raw sgr":    {"SGR 1 38:5:2", "\x1b[1;38:5:2m"},
+		"text":       {`TEXT "hi there"; "again"`, "hi thereagain"},
+		"hyperlink":  {"OSC8 https://charm.sh id=1", ansi.SetHyperlink("https://charm.sh", "id=1")},
+		"mnemonic":   {`CUP(10,20); OSC8(url="https://charm.sh")`, ansi.CursorPosition(20, 10) + ansi.SetHyperlink("https://charm.sh")},
+		"title":      {`OSC2 "hello world"`, ansi.SetWindowTitle("hello world")},
+		"registry":   {"ED 2; DECSCUSR 3; DECSC; DECRQM 1; XTVERSION", ansi.EraseEntireScreen + ansi.SetCursorStyle(3) + ansi.SaveCursor + "\x1b[1$p\x1b[>q"},
+		"controls":   {"CR; LF; BEL", "\r\n\a"},
+		"raw":        {`CSI "1;31m"; ESC 7; OSC "0;title"`, "\x1b[1;31m\x1b7\x1b]0;title\a"},
+		"osc number": {"OSC52 c aGk=", "\x1b]52;c;aGk=\a"},
+		"charset":    {"SCS G0 0; SCS(G1, B)", "\x1b(0\x1b)B"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := assemble(tc.src)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	for name, src := range map[string]string{
+		"unknown":       "FOO 1",
+		"attribute":     "SGR wat",
+		"color":         "SGR fg=octarine",
+		"parameter":     "CUU up",
+		"mode":          "DECSET mouse",
+		"unfinished":    `TEXT "hi`,
+		"position":      "CUP x y",
+		"negative":      "CUP -5 0",
+		"zero":          "CUP 1 0",
+		"too many":      "CUP 1 2 3",
+		"no charset":    "SCS",
+		"set":           "SCS G4 B",
+		"charset":       "SCS G0 Z",
+		"empty charset": `SCS G0 ""`,
+		"long charset":  "SCS G0 BB",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := assemble(src)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestMnemonicRoundTrip(t *testing.T) {
+	for name, table := range map[string]map[string]string{
+		"cursor":    cursor,
+		"line":      line,
+		"mode":      mode,
+		"kitty":     kitty,
+		"xterm":     xterm,
+		"sgr":       sgr,
+		"title":     title,
+		"hyperlink": hyperlink,
+		"clipboard": clipboard,
+		"others":    others,
+		"charset":   charset,
+	} {
+		t.Run(name, func(t *testing.T) {
+			for name, input := range table {
+				t.Run(name, func(t *testing.T) {
+					disassemble := func(in string) string {
+						var src []string
+						decode([]byte(in), func(e event) {
+							src = append(src, e.mnemonic())
+						})
+						return strings.Join(src, "\n")
+					}
+					// The bytes may differ, e.g. with omitted parameters,
+					// but the sequences must be the same.
+					src := disassemble(input)
+					got, err := assemble(src)
+					require.NoError(t, err, src)
+					require.Equal(t, src, disassemble(got))
+				})
+			}
+		})
+	}
+
+	t.Run("kitty key", func(t *testing.T) {
+		// Key events are named for what they are, not for SCORC.
+		var got []string
+		decode([]byte(ansi.PushKittyKeyboard(1)+"\x1b[97;6:3u"+ansi.PopKittyKeyboard(1)+"\x1b[u"), func(e event) {
+			got = append(got, e.mnemonic())
+		})
+		require.Equal(t, []string{"KITTYPUSH(1)", "KITTYKEY(97,6:3)", "KITTYPOP(1)", "SCORC"}, got)
+	})
+}
+
+func TestDocs(t *testing.T) {
+	for _, e := range entries() {
+		require.NotNil(t, e.doc, "no documentation for %s", e.name)
+		require.NotEmpty(t, e.doc.desc, e.name)
+		require.NotEmpty(t, e.doc.origin, e.name)
+		require.NotEmpty(t, e.doc.ref, e.name)
+	}
+
+	for _, query := range []string{"", "   ", "nope"} {
+		require.ErrorIs(t, printDoc(io.Discard, newTheme(), query), errNoDoc, query)
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	for name, tc := range map[string]struct {
+		input string
+		want  string
+	}{
+		"hex":          {`\x1b[1m\x9b2J`, "\x1b[1m\x9b2J"},
+		"octal":        {`\033[0m\0033[m\177`, "\x1b[0m\x1b[m\x7f"},
+		"bash":         {`\e]8;;url\e\\`, "\x1b]8;;url\x1b\\"},
+		"caret":        {`^[[H^[]0;title^G^?`, "\x1b[H\x1b]0;title\a\x7f"},
+		"json":         {`\u001b[1m\/\"`, "\x1b[1m/\""},
+		"go quoted":    {`"\x1b[1mhello\r\n"` + "\n", "\x1b[1mhello\r\n"},
+		"shell quoted": {`$'\E[1mhi\a'`, "\x1b[1mhi\a"},
+		"plain":        {`no escapes here`, "no escapes here"},
+		"incomplete":   {`\x \u12 trailing\`, `\x \u12 trailing\`},
+		"raw string":   {"`\\e[1m`", "`\x1b[1m`"},
+		"rune":         {`'\e'`, "'\x1b'"},
+		"octal byte":   {`\377\400\0777`, "\xff\x200\x3f7"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, string(unescape([]byte(tc.input))))
+		})
+	}
+
+	require.True(t, looksEscaped([]byte(`printf '\033[1m'`)))
+	require.False(t, looksEscaped([]byte("\x1b[1m and \\x1b")))
+	require.False(t, looksEscaped([]byte(`just a \n newline`)))
+	require.False(t, looksEscaped([]byte(`grep '^[a-z]' and \e alone`)))
+}
+
+func TestFlags(t *testing.T) {
+	input := ansi.SetModeAltScreenSaveCursor +
+		new(ansi.Style).Bold().ForegroundColor(ansi.Red).String() + "hello" +
+		ansi.ResetStyle + ansi.SetHyperlink("https://charm.sh") + "\r\n" +
+		"\x1b[1Z" + ansi.ResetModeAltScreenSaveCursor
+
+	frames := ansi.SetModeSynchronizedOutput + ansi.CursorHomePosition + ansi.EraseEntireScreen +
+		"hello\r\nworld" + ansi.ResetModeSynchronizedOutput +
+		ansi.SetModeSynchronizedOutput + ansi.CursorPosition(1, 2) + ansi.EraseEntireLine +
+		"there" + ansi.ResetModeSynchronizedOutput +
+		ansi.CursorHomePosition + "howdy"
+
+	wasteful := "\x1b[0m\x1b[1mA\x1b[1;31mB\x1b[38;2;0;0;0mC\x1b[1;1Hhi\x1b[1;3Hx\x1b[2;1Hy            \x1b[1Cz" +
+		"\x1b[H\x1b[2Jhi\x1b[H\x1b[2Jhi"
+
+	castV2 := `{"version": 2, "width": 80, "height": 24}
+[0.1, "o", "\u001b[?1049h\u001b[1;"]
+[0.25, "o", "31mhello\u001b[m"]
+[0.5, "i", "\u001b[A"]
+[0.75, "r", "100x30"]
+[1.0, "o", "\u001b[?1049l"]
+`
+	castV3 := `{"version": 3, "term": {"cols": 80, "rows": 24}}
+# a comment
+[0.1, "o", "\u001b[?1049h"]
+[0.4, "i", "q"]
+[0.1, "m", "quit"]
+[0.1, "o", "\u001b[?1049l"]
+[0.2, "x", "0"]
+`
+	castWide := `{"version": 2, "width": 100, "height": 3}
+[0.1, "o", "\u001b[H\u001b[2J\u001b[1;90Hwide"]
+[0.2, "r", "100x4"]
+[0.3, "o", "\u001b[H\u001b[2J\u001b[4;1Hlow"]
+`
+
+	for name, tc := range map[string]struct {
+		args  []string
+		input string
+	}{
+		"offsets":          {args: []string{"--offsets"}},
+		"hex":              {args: []string{"--hex"}},
+		"offsets hex":      {args: []string{"--offsets", "--hex"}},
+		"only":             {args: []string{"--only", "csi,unknown"}},
+		"exclude":          {args: []string{"--exclude", "Text,Ctrl"}},
+		"match":            {args: []string{"--match", "altscreen|Bold"}},
+		"grep":             {args: []string{"grep", "-B", "1", "hyperlink"}},
+		"grep context":     {args: []string{"grep", "-C", "1", "--only", "CSI", "altscreen"}},
+		"summary":          {args: []string{"--summary", "--top", "3"}},
+		"stats":            {args: []string{"stats", "--exclude", "text"}},
+		"frames":           {args: []string{"--frames"}, input: frames},
+		"frames render":    {args: []string{"--frames", "--render", "--exclude", "ctrl"}, input: frames},
+		"mnemonic":         {args: []string{"--mnemonic"}},
+		"mnemonic offsets": {args: []string{"--mnemonic", "--offsets"}, input: frames},
+		"unescape":         {args: []string{}, input: `\e[?1049h\x1b[1;31mhello^[[m\u001b]8;;https://charm.sh\a`},
+		"sequence":         {args: []string{"-e", `\033[2J`, "-e", `\e[H`}},
+		"cast":             {args: []string{}, input: castV2},
+		"cast v3":          {args: []string{"--offsets"}, input: castV3},
+		"cast raw":         {args: []string{"--raw"}, input: castV2},
+		"cast annotate":    {args: []string{"cast"}, input: castV2},
+		"cast annotate v3": {args: []string{"cast", "--exclude", "ctrl"}, input: castV3},
+		"list":             {args: []string{"list"}},
+		"doc":              {args: []string{"doc", "cup"}},
+		"doc sequence":     {args: []string{"doc", "CSI ? 1049 h"}},
+		"textconv":         {args: []string{"textconv", "--exclude", "ctrl"}},
+		"cast frames":      {args: []string{"--frames", "--render"}, input: castWide},
+		"optimize":         {args: []string{"optimize"}, input: wasteful},
+		"optimize rewrite": {args: []string{"optimize", "--rewrite"}, input: wasteful},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if tc.input == "" {
+				tc.input = input
+			}
+			var b bytes.Buffer
+			cmd := cmd()
+			cmd.SetOut(&b)
+			cmd.SetErr(&b)
+			cmd.SetIn(strings.NewReader(tc.input))
+			cmd.SetArgs(tc.args)
+			require.NoError(t, cmd.Execute())
+			golden.RequireEqual(t, b.Bytes())
+			// Commands leave the flags of the others alone.
+			require.Equal(t, slices.Contains(tc.args, "--offsets"), offsets)
+		})
+	}
+}
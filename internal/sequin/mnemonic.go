@@ -0,0 +1,177 @@
+package sequin
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+var mnemonics bool
+
+// mnemonic returns the event as a mnemonic with its decoded arguments, like
+// SGR(bold, fg=red) or CUP(10,20). The result can be read back by encode.
+// Text is quoted, and sequences without a handler are shown as their kind
+// and raw contents.
+func (e event) mnemonic() string {
+	switch {
+	case e.kind == "Text":
+		return strconv.Quote(string(e.seq))
+	case e.kind == "Ctrl":
+		for name, c := range ctrlNames {
+			if bytes.Equal(e.seq, []byte{c}) {
+				return name
+			}
+		}
+		return strconv.Quote(string(e.seq))
+	case e.name == "" || e.problem():
+		kind := e.kind
+		if kind == "" {
+			kind = unknown
+		}
+		return kind + ` "` + seqString(e.seq) + `"`
+	}
+
+	switch e.kind {
+	case "CSI":
+		if e.name == "SGR" {
+			return call(e.name, ", ", sgrMnemonics(e.params))
+		}
+		if cmd, _ := lookupCsi(e.name); cmd != e.cmd {
+			// Another sequence shares the mnemonic.
+			return `CSI "` + seqString(e.seq) + `"`
+		}
+		return call(e.name, ",", strings.Split(sgrString(e.params), ";"))
+	case "OSC":
+		_, data, _ := strings.Cut(oscData(e.seq), ";")
+		if e.name == "OSC8" {
+			params, url, _ := strings.Cut(data, ";")
+			args := []string{"url=" + quoteArg(url)}
+			if params != "" {
+				args = append(args, quoteArg(params))
+			}
+			return call(e.name, ", ", args)
+		}
+		var args []string
+		if data != "" {
+			args = strings.Split(data, ";")
+		}
+		for i, arg := range args {
+			args[i] = quoteArg(arg)
+		}
+		return call(e.name, ", ", args)
+	case "ESC":
+		if e.name == "SCS" {
+			// The set and the character set are operands, as in SCS(G0, 0).
+			return call(e.name, ", ", []string{fmt.Sprintf("G%d", e.cmd.Intermediate()-'('), string(e.cmd.Final())})
+		}
+		if cmd, _ := escHandler.lookup(e.name); cmd == e.cmd {
+			return e.name
+		}
+	}
+	return e.kind + ` "` + seqString(e.seq) + `"`
+}
+
+// oscData returns the contents of an OSC sequence, without its introducer
+// and terminator.
+func oscData(seq []byte) string {
+	s := string(seq)
+	s = strings.TrimPrefix(s, "\x1b]")
+	s = strings.TrimPrefix(s, "\x9d")
+	s = strings.TrimSuffix(s, "\a")
+	s = strings.TrimSuffix(s, "\x1b\\")
+	s = strings.TrimSuffix(s, "\x9c")
+	return s
+}
+
+// call formats a mnemonic and its arguments.
+func call(name, sep string, args []string) string {
+	if len(args) == 0 || len(args) == 1 && args[0] == "" {
+		return name
+	}
+	return name + "(" + strings.Join(args, sep) + ")"
+}
+
+// quoteArg quotes an argument if it couldn't be read back otherwise.
+func quoteArg(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"();,\\") {
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		if !strconv.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+var sgrNames = map[int]string{
+	0: "reset", 1: "bold", 2: "faint", 3: "italic", 4: "underline", 5: "blink",
+	6: "rapid-blink", 7: "reverse", 8: "conceal", 9: "strike",
+	22: "normal", 23: "no-italic", 24: "no-underline", 25: "no-blink",
+	27: "no-reverse", 28: "no-conceal", 29: "no-strike",
+	39: "fg=default", 49: "bg=default", 59: "ul=default",
+}
+
+// sgrMnemonics returns the attributes and colors set by SGR parameters, with
+// the names encode understands.
+//
+//nolint:mnd
+func sgrMnemonics(params ansi.Params) []string {
+	var args []string
+	for _, group := range sgrGroups(params) {
+		n := group[0].Param(0)
+		name, ok := sgrNames[n]
+		switch {
+		case n == 4 && len(group) > 1:
+			styles := []string{"none", "single", "double", "curly", "dotted", "dashed"}
+			if u := group[1].Param(0); u == 1 {
+				name = "underline"
+			} else if u < len(styles) {
+				name = "underline=" + styles[u]
+			} else {
+				ok = false
+			}
+		case n >= 30 && n <= 37:
+			name, ok = "fg="+colorNames[n-30], true
+		case n >= 90 && n <= 97:
+			name, ok = "fg=bright-"+colorNames[n-90], true
+		case n >= 40 && n <= 47:
+			name, ok = "bg="+colorNames[n-40], true
+		case n >= 100 && n <= 107:
+			name, ok = "bg=bright-"+colorNames[n-100], true
+		case n == 38, n == 48, n == 58:
+			name, ok = colorMnemonic(group)
+		}
+		if !ok {
+			// Raw parameters are read back as is.
+			name = sgrString(group)
+		}
+		args = append(args, name)
+	}
+	return args
+}
+
+// colorMnemonic returns an extended color as fg=, bg=, or ul= followed by a
+// palette index or a hex value.
+func colorMnemonic(group ansi.Params) (string, bool) {
+	key := map[int]string{38: "fg=", 48: "bg=", 58: "ul="}[group[0].Param(0)]
+	if len(group) < 2 { //nolint:mnd
+		return "", false
+	}
+	var c color.Color
+	if ansi.ReadStyleColor(group, &c) == 0 || c == nil {
+		return "", false
+	}
+	switch group[1].Param(0) {
+	case 5: //nolint:mnd
+		return key + strconv.Itoa(group[2].Param(0)), true
+	case 2: //nolint:mnd
+		r, g, b, _ := c.RGBA()
+		return key + fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8), true //nolint:mnd
+	}
+	return "", false
+}
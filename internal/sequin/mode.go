@@ -1,4 +1,4 @@
-package main
+package sequin
 
 import (
 	"fmt"
@@ -6,7 +6,7 @@ import (
 	"github.com/charmbracelet/x/ansi"
 )
 
-func handleMode(p *ansi.Parser) (string, error) {
+func handleMode(s *session, p *ansi.Parser) (string, error) {
 	var m int
 	if n, ok := p.Param(0, 0); ok {
 		m = n
@@ -16,6 +16,11 @@ func handleMode(p *ansi.Parser) (string, error) {
 	private := ""
 	if cmd.Prefix() == '?' {
 		private = "private "
+		if final := cmd.Final(); final == 'h' || final == 'l' {
+			for _, param := range p.Params() {
+				s.modes[param.Param(0)] = final == 'h'
+			}
+		}
 	}
 	switch cmd.Final() {
 	case 'p':
@@ -36,6 +41,8 @@ func modeDesc(mode int) string {
 		return "cursor keys"
 	case 25:
 		return "cursor visibility"
+	case 69:
+		return "left/right margins"
 	case 1000:
 		return "show mouse"
 	case 1001:
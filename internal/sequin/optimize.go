@@ -0,0 +1,538 @@
+package sequin
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+)
+
+var rewrite bool
+
+func optimizeCmd() *cobra.Command {
+	var width, height int
+	c := &cobra.Command{
+		Use:   "optimize [file]",
+		Short: "Find bytes a stream could do without",
+		Args:  cobra.MaximumNArgs(1),
+		Example: `
+# Find wasted bytes in the output of a TUI:
+sequin optimize ./testdata/output.golden
+
+# Write an equivalent, smaller stream:
+sequin optimize --rewrite <in >out
+
+# Optimize a stream written for a terminal of 120x40:
+sequin optimize --cols 120 --rows 40 <in
+	`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, err := readInput(cmd, args)
+			if err != nil {
+				return err
+			}
+			findings := optimize(in, width, height)
+			if rewrite {
+				_, err := cmd.OutOrStdout().Write(applyFindings(in, findings))
+				return err //nolint:wrapcheck
+			}
+			w := colorprofile.NewWriter(cmd.OutOrStdout(), os.Environ())
+			printFindings(w, newTheme(), in, findings)
+			return nil
+		},
+	}
+	c.Flags().BoolVarP(&rewrite, "rewrite", "w", false, "write the optimized stream instead of the findings")
+	c.Flags().IntVar(&width, "cols", 0, "width of the terminal the stream was written for (default: unknown, at least 80)")
+	c.Flags().IntVar(&height, "rows", 0, "height of the terminal the stream was written for (default: unknown, at least 24)")
+	return c
+}
+
+// finding is a part of the input that could be written with fewer bytes.
+type finding struct {
+	offset, length int
+
+	// replacement holds the bytes that could replace the finding.
+	replacement []byte
+	desc        string
+
+	// guess is set for findings that rely on a cursor position the
+	// optimizer can't be sure of. They are reported, but not rewritten.
+	guess bool
+}
+
+// savings returns the number of bytes the replacement saves.
+func (f finding) savings() int {
+	return f.length - len(f.replacement)
+}
+
+// optimize returns the findings for the input, written for a terminal of
+// the given size, or of an unknown one if zero. The findings are ordered by
+// offset, and never overlap, so that all of them can be applied together.
+func optimize(in []byte, width, height int) []finding {
+	var events []event
+	decode(in, func(e event) {
+		events = append(events, e)
+	})
+
+	o := optimizer{g: newGrid(recording{width: width, height: height}.size()), pen: pen{unknown: true}}
+	o.sized = width > 0 && height > 0
+	var findings []finding
+	var prev []byte
+	for _, fr := range splitFrames(events) {
+		before, placed := o.snapshot(), o.placed
+		var found []finding
+		for _, e := range fr.events {
+			if f, ok := o.check(e, nextEvent(events, e)); ok {
+				found = append(found, f)
+			}
+			o.apply(e)
+		}
+
+		b := frameBytes(fr)
+		if bytes.Equal(b, prev) && o.snapshot() == before {
+			// The frame draws exactly what's already on the screen.
+			findings = append(findings, finding{
+				offset: fr.events[0].offset,
+				length: len(b),
+				desc:   "Frame is identical to the previous one",
+				guess:  !placed || !o.placed,
+			})
+		} else {
+			findings = append(findings, found...)
+		}
+		prev = b
+	}
+	return findings
+}
+
+// nextEvent returns the event following e, or nil if e is the last one.
+func nextEvent(events []event, e event) *event {
+	i, ok := slices.BinarySearchFunc(events, e.offset, func(e event, offset int) int {
+		return cmp.Compare(e.offset, offset)
+	})
+	if !ok || i+1 >= len(events) {
+		return nil
+	}
+	return &events[i+1]
+}
+
+func frameBytes(fr frame) []byte {
+	var b []byte
+	for _, e := range fr.events {
+		b = append(b, e.seq...)
+	}
+	return b
+}
+
+// applyFindings returns the input with the findings replaced, except the
+// guesses.
+func applyFindings(in []byte, findings []finding) []byte {
+	var b bytes.Buffer
+	var offset int
+	for _, f := range findings {
+		if f.guess {
+			continue
+		}
+		b.Write(in[offset:f.offset])
+		b.Write(f.replacement)
+		offset = f.offset + f.length
+	}
+	b.Write(in[offset:])
+	return b.Bytes()
+}
+
+// printFindings writes the findings and the bytes they would save to w.
+func printFindings(w io.Writer, t theme, in []byte, findings []finding) {
+	var saved int
+	for _, f := range findings {
+		saved += f.savings()
+		_, _ = fmt.Fprint(w, t.sequence.Render(fmt.Sprintf("%08x  -%-4d", f.offset, f.savings())))
+		_, _ = fmt.Fprint(w, t.explanation.Render(f.desc))
+		if f.length < len(in) && f.length <= 32 { //nolint:mnd
+			_, _ = fmt.Fprint(w, t.separator, t.sequence.Render(fmt.Sprintf(
+				"%q → %q", in[f.offset:f.offset+f.length], f.replacement,
+			)))
+		}
+		if f.guess {
+			_, _ = fmt.Fprint(w, t.explanation.Render(" (not rewritten: the cursor may be elsewhere)"))
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+
+	var percent float64
+	if len(in) > 0 {
+		percent = float64(saved) * 100 / float64(len(in)) //nolint:mnd
+	}
+	_, _ = fmt.Fprintln(w, t.explanation.Bold(true).Render(fmt.Sprintf(
+		"%d findings, %d of %d bytes could be saved (%.1f%%)",
+		len(findings), saved, len(in), percent,
+	)))
+}
+
+// optimizer follows the state of the screen and the pen through a stream,
+// to tell which sequences have no effect.
+type optimizer struct {
+	g   *grid
+	pen pen
+
+	// placed reports whether the cursor is where the grid has it: it was
+	// put there with CUP, and since then it hasn't met the edge of the
+	// screen, where a terminal of another size would have put it
+	// elsewhere, unless sized says the size is known, nor moved in a way
+	// the grid doesn't model. savedPlaced is the same for the saved cursor.
+	placed, savedPlaced bool
+	sized               bool
+
+	// origin and margins are set while DECOM and DECLRMM are, which the
+	// grid doesn't model.
+	origin, margins bool
+}
+
+// state is what an optimizer knows of the terminal at some point.
+type state struct {
+	screen string
+	x, y   int
+	pen    pen
+}
+
+func (o *optimizer) snapshot() state {
+	return state{screen: o.g.String(), x: o.g.x, y: o.g.y, pen: o.pen}
+}
+
+//nolint:mnd
+func (o *optimizer) apply(e event) {
+	o.g.edge = false
+	_, _ = o.g.Write(e.seq)
+	switch {
+	case e.is("CSI", 'm'):
+		for _, group := range sgrGroups(sgrParams(e)) {
+			o.pen, _ = o.pen.apply(group)
+		}
+	case e.is("CSI", 'H'), e.is("CSI", 'f'):
+		o.placed = !o.origin && !o.margins
+	case e.is("CSI", 's') && !o.margins, e.is("ESC", '7'):
+		o.savedPlaced = o.placed
+	case e.is("CSI", 'u'), e.is("ESC", '8'):
+		o.placed = o.savedPlaced
+	case e.is("CSI", 'b'), e.is("CSI", 'I'), e.is("CSI", 'Z'), e.is("ESC", 'c'):
+		// Repeats, tabs, and resets the grid doesn't follow.
+		o.placed = false
+	case e.kind == "CSI" && e.cmd.Prefix() == '?' && (e.cmd.Final() == 'h' || e.cmd.Final() == 'l'):
+		set := e.cmd.Final() == 'h'
+		if e.hasParam(6) {
+			o.origin, o.placed = set, false
+		}
+		if e.hasParam(69) {
+			o.margins, o.placed = set, false
+		}
+	}
+	if o.g.edge && !o.sized {
+		o.placed = false
+	}
+}
+
+// sgrParams returns the parameters of an SGR sequence, where none means 0.
+func sgrParams(e event) ansi.Params {
+	if len(e.params) == 0 {
+		return ansi.Params{0}
+	}
+	return e.params
+}
+
+// check returns a finding for the event, given the event that follows it.
+func (o *optimizer) check(e event, next *event) (finding, bool) {
+	var replacement []byte
+	var desc string
+	switch {
+	case e.is("CSI", 'm'):
+		replacement, desc = o.checkSgr(e)
+	case e.is("CSI", 'H'), e.is("CSI", 'f'):
+		replacement, desc = o.checkCup(e)
+	case e.is("CSI", 'A'), e.is("CSI", 'B'), e.is("CSI", 'C'), e.is("CSI", 'D'):
+		if len(e.params) == 1 && e.param(0, 1) == 1 {
+			replacement = []byte("\x1b[" + string(e.cmd.Final()))
+			desc = "1 is the default"
+		}
+	case e.kind == "Text":
+		replacement, desc = o.checkSpaces(e, next)
+	}
+	if desc == "" || len(replacement) >= len(e.seq) {
+		return finding{}, false
+	}
+	return finding{
+		offset:      e.offset,
+		length:      len(e.seq),
+		replacement: replacement,
+		desc:        desc,
+		guess:       e.kind == "Text" && !o.placed,
+	}, true
+}
+
+// checkSgr drops the attributes of the SGR sequence that are already set,
+// and uses palette colors where they equal a 24-bit color.
+func (o *optimizer) checkSgr(e event) ([]byte, string) {
+	if len(e.params) == 1 && e.param(0, 0) == 0 && !e.params[0].HasMore() {
+		return []byte("\x1b[m"), "0 is the default"
+	}
+
+	p := o.pen
+	var kept []string
+	var reasons []string
+	for _, group := range sgrGroups(sgrParams(e)) {
+		next, known := p.apply(group)
+		if known && next == p {
+			reasons = append(reasons, descSgr(group)+" is already set")
+			continue
+		}
+		p = next
+		s := sgrString(group)
+		if n, ok := paletteIndex(group); ok {
+			indexed := fmt.Sprintf("%d;5;%d", group[0].Param(0), n)
+			if len(indexed) < len(s) {
+				reasons = append(reasons, fmt.Sprintf("%s equals palette color %d", descSgr(group), n))
+				s = indexed
+			}
+		}
+		kept = append(kept, s)
+	}
+	if len(reasons) == 0 {
+		return nil, ""
+	}
+	if len(kept) == 0 {
+		return nil, "Style is already active"
+	}
+	return []byte("\x1b[" + strings.Join(kept, ";") + "m"), strings.Join(reasons, ", ")
+}
+
+// checkCup finds shorter ways to move the cursor to the position of the CUP
+// sequence.
+func (o *optimizer) checkCup(e event) ([]byte, string) {
+	row, col := max(1, e.param(0, 1)), max(1, e.param(1, 1))
+	x, y := o.g.x, o.g.y
+	candidates := []string{cup(row, col)}
+	if x < o.g.width && o.placed {
+		// Relative moves need to know where the cursor is, and don't
+		// apply while it waits to wrap.
+		switch {
+		case row-1 == y && col-1 == x:
+			return nil, "Cursor is already there"
+		case row-1 == y && col == 1:
+			candidates = append(candidates, "\r")
+		case row-1 == y && col-1 == x-1:
+			candidates = append(candidates, "\b")
+		case row-1 == y && col-1 > x:
+			candidates = append(candidates, ansi.CursorForward(col-1-x))
+		case row-1 == y:
+			candidates = append(candidates, ansi.CursorBackward(x-col+1))
+		case row-1 == y+1 && col == 1 && y < o.g.bottom:
+			candidates = append(candidates, "\r\n")
+		case col-1 == x && o.g.top == 0 && o.g.bottom == o.g.height-1 && row-1 > y:
+			candidates = append(candidates, ansi.CursorDown(row-1-y))
+		case col-1 == x && o.g.top == 0 && o.g.bottom == o.g.height-1:
+			candidates = append(candidates, ansi.CursorUp(y-row+1))
+		}
+	}
+	best := slices.MinFunc(candidates, func(a, b string) int {
+		return cmp.Compare(len(a), len(b))
+	})
+	return []byte(best), "Shorter cursor movement"
+}
+
+// cup returns the shortest CUP sequence for the position.
+func cup(row, col int) string {
+	switch {
+	case row == 1 && col == 1:
+		return "\x1b[H"
+	case col == 1:
+		return "\x1b[" + strconv.Itoa(row) + "H"
+	default:
+		return "\x1b[" + strconv.Itoa(row) + ";" + strconv.Itoa(col) + "H"
+	}
+}
+
+// checkSpaces finds trailing spaces in text that could be erased instead.
+func (o *optimizer) checkSpaces(e event, next *event) ([]byte, string) {
+	text := strings.TrimRight(string(e.seq), " ")
+	n := len(e.seq) - len(text)
+	if n == 0 || o.pen.visibleSpaces() {
+		return nil, ""
+	}
+	end := o.g.x + ansi.StringWidth(string(e.seq))
+	if o.g.x >= o.g.width || end > o.g.width {
+		// Wrapping text is left alone.
+		return nil, ""
+	}
+	if end == o.g.width {
+		if next != nil && (bytes.Equal(next.seq, []byte{ansi.CR}) || next.is("CSI", 'H') || next.is("CSI", 'f')) {
+			// The cursor moves away right after the line is filled.
+			return []byte(text + ansi.EraseLineRight), "Trailing spaces could be erased with EL"
+		}
+		// Moving the cursor forward can't leave it waiting to wrap.
+		return nil, ""
+	}
+	return []byte(text + ansi.EraseCharacter(n) + ansi.CursorForward(n)), "Spaces could be erased with ECH"
+}
+
+// pen is the style text is written with.
+type pen struct {
+	bold, faint, italic, blink, inverse, conceal, strike bool
+
+	// unknown is set while the pen may have attributes it doesn't model:
+	// at the start of a stream, and after an attribute it doesn't know,
+	// until a reset.
+	unknown bool
+
+	underline int
+	fg, bg    string
+	ul        string
+}
+
+// visibleSpaces reports whether spaces written with the pen look different
+// from erased cells.
+func (p pen) visibleSpaces() bool {
+	return p.unknown || p.underline != 0 || p.inverse || p.strike
+}
+
+// apply returns the pen after the SGR parameter group. known is false for
+// attributes the pen doesn't model.
+//
+//nolint:mnd,cyclop
+func (p pen) apply(group ansi.Params) (_ pen, known bool) {
+	switch n := group[0].Param(0); {
+	case n == 0:
+		return pen{}, true
+	case n == 1:
+		p.bold = true
+	case n == 2:
+		p.faint = true
+	case n == 3:
+		p.italic = true
+	case n == 4:
+		p.underline = 1
+		if len(group) > 1 {
+			p.underline = group[1].Param(0)
+		}
+	case n == 5, n == 6:
+		p.blink = true
+	case n == 7:
+		p.inverse = true
+	case n == 8:
+		p.conceal = true
+	case n == 9:
+		p.strike = true
+	case n == 21:
+		p.underline = 2
+	case n == 22:
+		p.bold, p.faint = false, false
+	case n == 23:
+		p.italic = false
+	case n == 24:
+		p.underline = 0
+	case n == 25:
+		p.blink = false
+	case n == 27:
+		p.inverse = false
+	case n == 28:
+		p.conceal = false
+	case n == 29:
+		p.strike = false
+	case n >= 30 && n <= 37, n >= 90 && n <= 97:
+		p.fg = strconv.Itoa(n)
+	case n == 38:
+		p.fg = colorKey(group)
+	case n == 39:
+		p.fg = ""
+	case n >= 40 && n <= 47, n >= 100 && n <= 107:
+		p.bg = strconv.Itoa(n)
+	case n == 48:
+		p.bg = colorKey(group)
+	case n == 49:
+		p.bg = ""
+	case n == 58:
+		p.ul = colorKey(group)
+	case n == 59:
+		p.ul = ""
+	default:
+		p.unknown = true
+		return p, false
+	}
+	return p, true
+}
+
+// colorKey identifies the color set by an extended color group.
+func colorKey(group ansi.Params) string {
+	var c color.Color
+	if ansi.ReadStyleColor(group, &c) == 0 || c == nil {
+		return sgrString(group)
+	}
+	r, g, b, a := c.RGBA()
+	return fmt.Sprintf("%d:%d:%d:%d:%d", group[1].Param(0), r, g, b, a)
+}
+
+// paletteIndex returns the 256-color palette entry equal to the 24-bit
+// color of the group, if any. The first 16 entries are left out, as they
+// depend on the terminal.
+func paletteIndex(group ansi.Params) (int, bool) {
+	if len(group) < 5 || group[1].Param(0) != 2 { //nolint:mnd
+		return 0, false
+	}
+	var c color.Color
+	if ansi.ReadStyleColor(group, &c) == 0 || c == nil {
+		return 0, false
+	}
+	r, g, b, _ := c.RGBA()
+	for i := 16; i < 256; i++ {
+		pr, pg, pb, _ := ansi.IndexedColor(i).RGBA() //nolint:gosec
+		if r == pr && g == pg && b == pb {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// sgrGroups splits SGR parameters into the groups that make up a single
+// attribute, like an extended color and its components.
+func sgrGroups(params ansi.Params) []ansi.Params {
+	var groups []ansi.Params
+	for i := 0; i < len(params); {
+		n := 1
+		switch params[i].Param(0) {
+		case 38, 48, 58: //nolint:mnd
+			var c color.Color
+			n = max(1, ansi.ReadStyleColor(params[i:], &c))
+		}
+		for params[i+n-1].HasMore() && i+n < len(params) {
+			// Sub-parameters belong to the same attribute.
+			n++
+		}
+		groups = append(groups, params[i:i+n])
+		i += n
+	}
+	return groups
+}
+
+// sgrString returns the group as it's written in a sequence.
+func sgrString(group ansi.Params) string {
+	var b strings.Builder
+	for i, p := range group {
+		if v := p.Param(-1); v >= 0 {
+			b.WriteString(strconv.Itoa(v))
+		}
+		if i < len(group)-1 {
+			if p.HasMore() {
+				b.WriteByte(':')
+			} else {
+				b.WriteByte(';')
+			}
+		}
+	}
+	return b.String()
+}
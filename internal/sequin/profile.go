@@ -0,0 +1,138 @@
+package sequin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+)
+
+var (
+	cols, rows     int
+	termName       string
+	envVars        []string
+	profileName    string
+	separateStderr bool
+)
+
+var (
+	errEnv     = errors.New("invalid environment variable")
+	errProfile = errors.New("unknown profile")
+)
+
+// terminal is the terminal an executed command runs in. With stderr set,
+// the command writes its errors to a pipe instead.
+type terminal struct {
+	width, height int
+	env           []string
+	stderr        bool
+}
+
+// newTerminal returns the terminal set by the flags: the size of ours unless
+// given, and our environment, changed by the profile, --term, and --env.
+func newTerminal() (terminal, error) {
+	width, height := termSize()
+	env, err := environ(os.Environ())
+	return terminal{width, height, env, separateStderr}, err
+}
+
+// termSize returns the size of our terminal, unless set by --cols and
+// --rows.
+func termSize() (width, height int) {
+	width, height, err := term.GetSize(os.Stdout.Fd())
+	if err != nil {
+		width, height = defaultWidth, defaultHeight
+	}
+	if cols > 0 {
+		width = cols
+	}
+	if rows > 0 {
+		height = rows
+	}
+	return width, height
+}
+
+// detectionVars are the variables programs look at to tell terminals apart.
+// A profile replaces all of them.
+var detectionVars = []string{
+	"TERM", "COLORTERM", "TERM_PROGRAM", "TERM_PROGRAM_VERSION", "TERMINAL_EMULATOR",
+	"KITTY_WINDOW_ID", "KITTY_PID", "WEZTERM_EXECUTABLE", "WEZTERM_PANE", "ITERM_SESSION_ID",
+	"LC_TERMINAL", "LC_TERMINAL_VERSION", "GHOSTTY_RESOURCES_DIR", "ALACRITTY_WINDOW_ID",
+	"WT_SESSION", "VTE_VERSION", "KONSOLE_VERSION", "XTERM_VERSION", "TMUX", "STY", "SSH_TTY",
+}
+
+// profiles are the environments of well-known terminals.
+var profiles = map[string][]string{
+	"alacritty":        {"TERM=alacritty", "COLORTERM=truecolor", "ALACRITTY_WINDOW_ID=1"},
+	"apple-terminal":   {"TERM=xterm-256color", "TERM_PROGRAM=Apple_Terminal", "TERM_PROGRAM_VERSION=455"},
+	"dumb":             {"TERM=dumb"},
+	"ghostty":          {"TERM=xterm-ghostty", "COLORTERM=truecolor", "TERM_PROGRAM=ghostty", "GHOSTTY_RESOURCES_DIR=/usr/share/ghostty"},
+	"iterm2":           {"TERM=xterm-256color", "COLORTERM=truecolor", "TERM_PROGRAM=iTerm.app", "LC_TERMINAL=iTerm2", "ITERM_SESSION_ID=w0t0p0"},
+	"kitty":            {"TERM=xterm-kitty", "COLORTERM=truecolor", "KITTY_WINDOW_ID=1"},
+	"linux":            {"TERM=linux"},
+	"screen":           {"TERM=screen", "STY=1.pts-0.host"},
+	"tmux":             {"TERM=tmux-256color", "TERM_PROGRAM=tmux", "TMUX=/tmp/tmux-1000/default,1,0"},
+	"vscode":           {"TERM=xterm-256color", "COLORTERM=truecolor", "TERM_PROGRAM=vscode"},
+	"vte":              {"TERM=xterm-256color", "COLORTERM=truecolor", "VTE_VERSION=7600"},
+	"wezterm":          {"TERM=xterm-256color", "COLORTERM=truecolor", "TERM_PROGRAM=WezTerm", "WEZTERM_PANE=0"},
+	"windows-terminal": {"TERM=xterm-256color", "WT_SESSION=00000000-0000-0000-0000-000000000000"},
+	"xterm":            {"TERM=xterm-256color", "XTERM_VERSION=XTerm(390)"},
+}
+
+// profileNames returns the names of the profiles, sorted.
+func profileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// environ returns the environment of an executed command: base, with the
+// variables of the profile, TERM and COLORTERM from --term, and --env.
+func environ(base []string) ([]string, error) {
+	env := slices.Clone(base)
+	if profileName != "" {
+		vars, ok := profiles[strings.ToLower(profileName)]
+		if !ok {
+			return nil, fmt.Errorf("%w %q: use one of %s", errProfile, profileName, strings.Join(profileNames(), ", "))
+		}
+		env = slices.DeleteFunc(env, func(kv string) bool {
+			key, _, _ := strings.Cut(kv, "=")
+			return slices.Contains(detectionVars, key)
+		})
+		env = append(env, vars...)
+	}
+	if termName != "" {
+		env = setEnv(env, "TERM="+termName)
+		if strings.HasSuffix(termName, "-direct") || strings.Contains(termName, "truecolor") {
+			env = setEnv(env, "COLORTERM=truecolor")
+		} else {
+			env = setEnv(env, "COLORTERM")
+		}
+	}
+	for _, kv := range envVars {
+		if key, _, ok := strings.Cut(kv, "="); !ok || key == "" {
+			return nil, fmt.Errorf("%w %q: expected KEY=VALUE", errEnv, kv)
+		}
+		env = setEnv(env, kv)
+	}
+	return env, nil
+}
+
+// setEnv sets KEY=VALUE in the environment, or removes KEY when there's no
+// value.
+func setEnv(env []string, kv string) []string {
+	key, _, set := strings.Cut(kv, "=")
+	env = slices.DeleteFunc(env, func(s string) bool {
+		return strings.HasPrefix(s, key+"=")
+	})
+	if set {
+		env = append(env, kv)
+	}
+	return env
+}
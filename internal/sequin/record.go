@@ -0,0 +1,164 @@
+package sequin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	timestamps   string
+	recordFile   string
+	recordTiming string
+)
+
+var errTimestamps = errors.New("invalid timestamps")
+
+// checkTimestamps validates --timestamps.
+func checkTimestamps() error {
+	switch timestamps {
+	case "", "abs", "rel":
+		return nil
+	}
+	return fmt.Errorf("%w %q: use abs or rel", errTimestamps, timestamps)
+}
+
+// formatTime returns the time of an event: the time of day when the start
+// is given, and otherwise the time since the start.
+func formatTime(at time.Duration, start time.Time) string {
+	if !start.IsZero() {
+		return fmt.Sprintf("%10s", start.Add(at).Format("15:04:05.000"))
+	}
+	return fmt.Sprintf("%9.3fs", at.Seconds())
+}
+
+// record saves the capture to --record: as an asciinema cast, or as a
+// typescript when --record-timing names the file for its timing.
+//
+//nolint:wrapcheck
+func record(c capture) error {
+	if recordFile == "" {
+		return nil
+	}
+	f, err := os.Create(recordFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+
+	if recordTiming == "" {
+		err = writeCast(f, c)
+	} else {
+		var timing *os.File
+		timing, err = os.Create(recordTiming)
+		if err != nil {
+			return err
+		}
+		defer timing.Close() //nolint:errcheck
+		err = writeTypescript(f, timing, c)
+		if err == nil {
+			err = timing.Close()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// writeCast writes the capture as an asciinema cast, version 2.
+//
+//nolint:wrapcheck
+func writeCast(w io.Writer, c capture) error {
+	header, err := json.Marshal(struct {
+		Version   int    `json:"version"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+		Timestamp int64  `json:"timestamp"`
+		Command   string `json:"command"`
+	}{2, c.width, c.height, c.start.Unix(), strings.Join(c.args, " ")}) //nolint:mnd
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", header); err != nil {
+		return err
+	}
+
+	line := func(at time.Duration, stream string, data []byte) error {
+		s, err := json.Marshal(string(data))
+		if err != nil {
+			return err
+		}
+		if stream == streamError {
+			// Casts have no stderr, but it was on the screen all the same.
+			stream = streamOutput
+		}
+		_, err = fmt.Fprintf(w, "[%.6f, %q, %s]\n", at.Seconds(), stream, s)
+		return err
+	}
+
+	var pending []byte
+	var last time.Duration
+	for _, ch := range c.chunks {
+		if ch.stream == streamExit {
+			// Exit events only exist in version 3.
+			continue
+		}
+		data := ch.data
+		if ch.stream == streamOutput {
+			// JSON strings hold text, so runes split between reads are joined.
+			data = append(pending, ch.data...) //nolint:gocritic
+			n := len(data)
+			for i := 1; i < utf8.UTFMax && i <= n; i++ {
+				if utf8.RuneStart(data[n-i]) {
+					if !utf8.FullRune(data[n-i:]) {
+						n -= i
+					}
+					break
+				}
+			}
+			data, pending, last = data[:n], append([]byte(nil), data[n:]...), ch.at
+			if len(data) == 0 {
+				continue
+			}
+		}
+		if err := line(ch.at, ch.stream, data); err != nil {
+			return err
+		}
+	}
+	if len(pending) > 0 {
+		// The output ended in the middle of a rune.
+		return line(last, streamOutput, pending)
+	}
+	return nil
+}
+
+// writeTypescript writes the capture as script(1) does with -t: the output
+// after a header line, and the timing in the classic format.
+//
+//nolint:wrapcheck
+func writeTypescript(w, timing io.Writer, c capture) error {
+	if _, err := fmt.Fprintf(w, "Script started on %s [COMMAND=%q]\n", c.start.Format("2006-01-02 15:04:05-07:00"), strings.Join(c.args, " ")); err != nil {
+		return err
+	}
+	var last time.Duration
+	for _, ch := range c.chunks {
+		if ch.stream != streamOutput && ch.stream != streamError {
+			// The classic format only has the output.
+			continue
+		}
+		if _, err := w.Write(ch.data); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(timing, "%.6f %d\n", (ch.at - last).Seconds(), len(ch.data)); err != nil {
+			return err
+		}
+		last = ch.at
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package sequin
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// rectNumParams is the number of parameters used to describe a rectangle.
+const rectNumParams = 4
+
+//nolint:mnd
+func handleRect(_ *session, p *ansi.Parser) (string, error) {
+	cmd := ansi.Cmd(p.Command())
+	switch cmd.Intermediate() {
+	case '$':
+		switch cmd.Final() {
+		case 'v':
+			// DECCRA - Copy Rectangular Area
+			src := descRect(p, 0)
+			srcPage := descPage(p, 4)
+			top, _ := p.Param(5, 1)
+			left, _ := p.Param(6, 1)
+			dstPage := descPage(p, 7)
+			return fmt.Sprintf(
+				"Copy rectangle %s on page %s to top=%d left=%d on page %s",
+				src, srcPage, default1(top), default1(left), dstPage,
+			), nil
+		case 'x':
+			// DECFRA - Fill Rectangular Area
+			ch, _ := p.Param(0, 0)
+			return fmt.Sprintf("Fill rectangle %s with %q", descRect(p, 1), rune(ch)), nil
+		case 'z':
+			// DECERA - Erase Rectangular Area
+			return fmt.Sprintf("Erase rectangle %s", descRect(p, 0)), nil
+		case '{':
+			// DECSERA - Selective Erase Rectangular Area
+			return fmt.Sprintf("Selectively erase rectangle %s", descRect(p, 0)), nil
+		case 'r':
+			// DECCARA - Change Attributes in Rectangular Area
+			return fmt.Sprintf("Change attributes in rectangle %s: %s", descRect(p, 0), descRectAttrs(p)), nil
+		case 't':
+			// DECRARA - Reverse Attributes in Rectangular Area
+			return fmt.Sprintf("Reverse attributes in rectangle %s: %s", descRect(p, 0), descRectAttrs(p)), nil
+		}
+	case '*':
+		switch cmd.Final() {
+		case 'x':
+			// DECSACE - Select Attribute Change Extent
+			n, _ := p.Param(0, 0)
+			switch n {
+			case 0, 1:
+				return "Set attribute change extent to stream", nil
+			case 2:
+				return "Set attribute change extent to rectangle", nil
+			}
+			return "", errInvalid
+		case 'y':
+			// DECRQCRA - Request Checksum of Rectangular Area
+			id, _ := p.Param(0, 0)
+			return fmt.Sprintf(
+				"Request checksum of rectangle %s on page %s (id=%d)",
+				descRect(p, 2), descPage(p, 1), id,
+			), nil
+		}
+	case '"':
+		if cmd.Final() == 'q' {
+			// DECSCA - Select Character Protection Attribute
+			n, _ := p.Param(0, 0)
+			switch n {
+			case 0, 2:
+				return "Set characters as erasable by selective erase", nil
+			case 1:
+				return "Set characters as protected from selective erase", nil
+			}
+			return "", errInvalid
+		}
+	}
+	return "", errUnhandled
+}
+
+// descRect describes the rectangle whose top, left, bottom, and right
+// coordinates start at the given parameter index. A missing or zero bottom or
+// right edge means the last row or column.
+func descRect(p *ansi.Parser, i int) string {
+	top, _ := p.Param(i, 1)
+	left, _ := p.Param(i+1, 1)
+	edge := func(j int) string {
+		if n, _ := p.Param(j, 0); n > 0 {
+			return fmt.Sprintf("%d", n)
+		}
+		return "last"
+	}
+	return fmt.Sprintf(
+		"top=%d left=%d bottom=%s right=%s",
+		default1(top), default1(left), edge(i+2), edge(i+3),
+	)
+}
+
+// descPage describes the page number at the given parameter index.
+func descPage(p *ansi.Parser, i int) string {
+	n, _ := p.Param(i, 1)
+	return fmt.Sprintf("%d", default1(n))
+}
+
+// descRectAttrs describes the attributes following the rectangle coordinates
+// of DECCARA and DECRARA in the same terms as SGR.
+func descRectAttrs(p *ansi.Parser) string {
+	params := p.Params()
+	if len(params) <= rectNumParams {
+		return descSgr(nil)
+	}
+	return descSgr(params[rectNumParams:])
+}
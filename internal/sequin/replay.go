@@ -0,0 +1,293 @@
+package sequin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var errNotTerminal = errors.New("replay needs a terminal")
+
+const replayHelp = "space step · f frame · / search · n next · c continue · q quit"
+
+func replayCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "replay file",
+		Short: "Replay a recorded stream on the terminal, one sequence at a time",
+		Long: `Replay a recorded stream on the terminal, one sequence at a time.
+
+The stream is drawn on the alternate screen, and the last row shows the
+sequence that was just applied. Keys:
+
+  space, enter, j   apply the next sequence
+  f                 apply the rest of the frame
+  /                 apply sequences until one matches a pattern
+  n                 apply sequences until the next match
+  c                 apply the rest of the stream
+  q, ctrl+c         quit`,
+		Args: cobra.ExactArgs(1),
+		Example: `
+# Watch a render go wrong, sequence by sequence:
+sequin replay testdata/TestApp.golden
+sequin replay session.cast
+	`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, err := os.ReadFile(args[0])
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+			rec, err := readChunks(in)
+			if err != nil {
+				return err
+			}
+			var events []event
+			decodeChunks(rec.chunks, func(e event) {
+				if e.onScreen() {
+					events = append(events, e)
+				}
+			})
+
+			fd := os.Stdin.Fd()
+			if !term.IsTerminal(fd) {
+				return errNotTerminal
+			}
+			width, height, err := term.GetSize(os.Stdout.Fd())
+			if err != nil {
+				width, height = defaultWidth, defaultHeight
+			}
+			state, err := term.MakeRaw(fd)
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+			defer term.Restore(fd, state) //nolint:errcheck
+
+			w := cmd.OutOrStdout()
+			_, _ = io.WriteString(w, ansi.SetModeAltScreenSaveCursor+ansi.EraseEntireScreen+ansi.CursorHomePosition)
+			defer func() {
+				// Undo what the stream may have left behind.
+				_, _ = io.WriteString(w, "\x1b[!p"+ansi.ResetMode(
+					ansi.ModeMouseNormal, ansi.ModeMouseButtonEvent, ansi.ModeMouseAnyEvent,
+					ansi.ModeMouseExtSgr, ansi.ModeFocusEvent, ansi.ModeBracketedPaste,
+				)+ansi.ShowCursor+ansi.ResetModeAltScreenSaveCursor)
+			}()
+			return newReplayer(events, width, height).run(keyReader{bufio.NewReader(os.Stdin)}, w)
+		},
+	}
+}
+
+// replayer writes events to a terminal as it is told to by keys.
+type replayer struct {
+	events []event
+
+	// frameEnd is set for the last event of each frame.
+	frameEnd []bool
+
+	// pos is the number of events applied.
+	pos int
+
+	search        *regexp.Regexp
+	width, height int
+}
+
+func newReplayer(events []event, width, height int) *replayer {
+	r := &replayer{events: events, frameEnd: make([]bool, len(events)), width: width, height: height}
+	var n int
+	for _, f := range splitFrames(events) {
+		n += len(f.events)
+		r.frameEnd[n-1] = true
+	}
+	return r
+}
+
+// run reads keys until q, and applies events accordingly.
+func (r *replayer) run(keys io.ByteReader, w io.Writer) error {
+	r.status(w, fmt.Sprintf("%d sequences · %s", len(r.events), replayHelp))
+	for {
+		k, err := keys.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		msg := ""
+		switch k {
+		case ' ', '\r', '\n', 'j':
+			r.advance(w, func(int) bool { return true })
+		case 'f':
+			r.advance(w, func(i int) bool { return r.frameEnd[i] })
+		case '/':
+			pattern, ok, err := r.prompt(keys, w)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				break
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				msg = err.Error()
+				break
+			}
+			r.search = re
+			msg = r.find(w)
+		case 'n':
+			if r.search == nil {
+				msg = "no pattern, search with /"
+				break
+			}
+			msg = r.find(w)
+		case 'c':
+			r.advance(w, func(int) bool { return false })
+		case 'q', 3: //nolint:mnd
+			return nil
+		default:
+			msg = replayHelp
+		}
+		r.status(w, r.describe(msg))
+	}
+}
+
+// keyReader reads keys from the terminal, and drops the replies it sends to
+// the queries in the stream, like DA1 or DSR, so they aren't taken for keys.
+// An escape followed by more in the same read starts a reply; a lone one is
+// the escape key.
+type keyReader struct {
+	r *bufio.Reader
+}
+
+func (k keyReader) ReadByte() (byte, error) {
+	for {
+		b, err := k.r.ReadByte()
+		if err != nil || b != ansi.ESC || k.r.Buffered() == 0 {
+			return b, err //nolint:wrapcheck
+		}
+		if err := skipReply(k.r); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// skipReply reads the rest of a sequence after its escape: a CSI up to its
+// final byte, a string up to BEL or ST, or an escape sequence up to its final
+// byte.
+func skipReply(r io.ByteReader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	switch {
+	case b == '[':
+		for {
+			if b, err = r.ReadByte(); err != nil {
+				return err //nolint:wrapcheck
+			}
+			if b >= 0x40 && b <= 0x7e {
+				return nil
+			}
+		}
+	case b == ']' || b == 'P' || b == '_' || b == '^' || b == 'X':
+		for prev := b; ; prev = b {
+			if b, err = r.ReadByte(); err != nil {
+				return err //nolint:wrapcheck
+			}
+			if b == ansi.BEL || prev == ansi.ESC && b == '\\' {
+				return nil
+			}
+		}
+	case b == 'O':
+		// SS3, and the byte it shifts.
+		_, err = r.ReadByte()
+		return err //nolint:wrapcheck
+	default:
+		for b >= 0x20 && b <= 0x2f {
+			if b, err = r.ReadByte(); err != nil {
+				return err //nolint:wrapcheck
+			}
+		}
+	}
+	return nil
+}
+
+// advance applies events until stop returns true for one of them, or the
+// stream ends.
+func (r *replayer) advance(w io.Writer, stop func(int) bool) {
+	for r.pos < len(r.events) {
+		i := r.pos
+		_, _ = w.Write(r.events[i].seq)
+		r.pos++
+		if stop(i) {
+			return
+		}
+	}
+}
+
+// find applies events until one matches the search, and returns a message
+// when none does.
+func (r *replayer) find(w io.Writer) string {
+	var found bool
+	r.advance(w, func(i int) bool {
+		found = r.search.MatchString(r.events[i].String())
+		return found
+	})
+	if !found {
+		return "no match for " + r.search.String()
+	}
+	return ""
+}
+
+// prompt reads a pattern on the status line, and reports whether it was
+// entered rather than canceled with escape.
+func (r *replayer) prompt(keys io.ByteReader, w io.Writer) (string, bool, error) {
+	var pattern []byte
+	for {
+		r.status(w, "/"+string(pattern))
+		k, err := keys.ReadByte()
+		if err != nil {
+			return "", false, err //nolint:wrapcheck
+		}
+		switch k {
+		case '\r', '\n':
+			return string(pattern), true, nil
+		case 0x1b, 3: //nolint:mnd
+			return "", false, nil
+		case 0x7f, '\b':
+			if len(pattern) > 0 {
+				pattern = pattern[:len(pattern)-1]
+			}
+		default:
+			pattern = append(pattern, k)
+		}
+	}
+}
+
+// describe returns the status after applying events: the position, the last
+// event, and a message.
+func (r *replayer) describe(msg string) string {
+	s := fmt.Sprintf("%d/%d", r.pos, len(r.events))
+	if r.pos > 0 {
+		s += " " + diffLine(r.events[r.pos-1])
+	}
+	if r.pos == len(r.events) {
+		s += " · end"
+	}
+	if msg != "" {
+		s += " · " + msg
+	}
+	return s
+}
+
+// status writes s on the last row of the screen, leaving the cursor and the
+// pen of the stream as they were.
+func (r *replayer) status(w io.Writer, s string) {
+	_, _ = io.WriteString(w, ansi.SaveCursor+ansi.CursorPosition(1, r.height)+ansi.EraseEntireLine+
+		new(ansi.Style).Reverse(true).String()+ansi.Truncate(s, r.width, "…")+ansi.RestoreCursor)
+}
@@ -0,0 +1,39 @@
+package sequin
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+//nolint:mnd
+func handleReset(s *session, p *ansi.Parser) (string, error) {
+	cmd := ansi.Cmd(p.Command())
+	switch cmd.Intermediate() {
+	case '!':
+		// DECSTR - Soft Terminal Reset
+		s.softReset()
+		return "Soft terminal reset", nil
+	case '"':
+		// DECSCL - Select Conformance Level
+		level, _ := p.Param(0, 0)
+		if level < 61 || level > 65 {
+			return "", errInvalid
+		}
+		desc := fmt.Sprintf("Set conformance level to VT%d00", level-60)
+		if level == 61 {
+			// VT100 mode always uses 7-bit controls.
+			return desc, nil
+		}
+		switch n, _ := p.Param(1, 0); n {
+		case 0, 2:
+			desc += " with 8-bit controls"
+		case 1:
+			desc += " with 7-bit controls"
+		default:
+			return "", errInvalid
+		}
+		return desc, nil
+	}
+	return "", errUnhandled
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package sequin
+
+import "os"
+
+// notifyResize relays the resizes of our terminal to c. Windows has no
+// signal for them, so the size of the command stays as it started.
+func notifyResize(chan<- os.Signal) {}
@@ -1,4 +1,4 @@
-package main
+package sequin
 
 import (
 	"fmt"
@@ -7,7 +7,7 @@ import (
 )
 
 //nolint:mnd
-func handleScreen(p *ansi.Parser) (string, error) {
+func handleScreen(_ *session, p *ansi.Parser) (string, error) {
 	var count int
 	if n, ok := p.Param(0, 0); ok {
 		count = n
@@ -16,6 +16,18 @@ func handleScreen(p *ansi.Parser) (string, error) {
 	cmd := ansi.Cmd(p.Command())
 	switch cmd.Final() {
 	case 'J':
+		if cmd.Prefix() == '?' {
+			// DECSED - Selective Erase in Display
+			switch count {
+			case 0:
+				return "Selectively erase screen below", nil
+			case 1:
+				return "Selectively erase screen above", nil
+			case 2:
+				return "Selectively erase entire screen", nil
+			}
+			return "", errInvalid
+		}
 		switch count {
 		case 0:
 			return "Erase screen bellow", nil
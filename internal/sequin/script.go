@@ -0,0 +1,136 @@
+package sequin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timingFile string
+
+var (
+	errTiming        = errors.New("invalid timing file")
+	errTimingCommand = errors.New("--timing reads a recording, not the output of a command")
+)
+
+// readTypescript reads a typescript recorded by script(1) with the timing file
+// written by -t or -T. The timing file is either in the classic format, with
+// lines of "delay bytes", or in the advanced one, where each line starts with
+// the stream: I and O for input and output, S for signals like a resize, and
+// H for the header. Input and output are read from the same typescript, as
+// logged by script -B.
+func readTypescript(typescript, timing []byte) ([]chunk, error) {
+	if line, rest, ok := bytes.Cut(typescript, []byte("\n")); ok && bytes.HasPrefix(line, []byte("Script started on ")) {
+		// The header isn't part of the timing.
+		typescript = rest
+	}
+
+	var chunks []chunk
+	var at time.Duration
+	s := bufio.NewScanner(bytes.NewReader(timing))
+	for n := 1; s.Scan(); n++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		stream := "O"
+		if _, err := strconv.ParseFloat(fields[0], 64); err != nil {
+			// Advanced format.
+			stream, fields = fields[0], fields[1:]
+		}
+		if len(fields) < 2 { //nolint:mnd
+			return nil, fmt.Errorf("%w: line %d: %q", errTiming, n, s.Text())
+		}
+		delay, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", errTiming, n, err)
+		}
+		at += time.Duration(delay * float64(time.Second))
+
+		switch stream {
+		case "O", "I":
+			size, err := strconv.Atoi(fields[1])
+			if err != nil || size < 0 {
+				return nil, fmt.Errorf("%w: line %d: bad size %q", errTiming, n, fields[1])
+			}
+			size = min(size, len(typescript))
+			c := chunk{stream: streamOutput, at: at, data: typescript[:size]}
+			if stream == "I" {
+				c.stream = streamInput
+			}
+			chunks = append(chunks, c)
+			typescript = typescript[size:]
+		case "S":
+			c := chunk{stream: streamMarker, at: at, data: []byte(strings.Join(fields[1:], " "))}
+			if fields[1] == "SIGWINCH" {
+				c.stream, c.data = streamResize, []byte(winsize(fields[2:]))
+			}
+			chunks = append(chunks, c)
+		case "H":
+			// Header information, like the command and the terminal.
+		default:
+			return nil, fmt.Errorf("%w: line %d: unknown stream %q", errTiming, n, stream)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errTiming, err)
+	}
+	return chunks, nil
+}
+
+// winsize returns the size in the fields of a SIGWINCH entry, like
+// "ROWS=24 COLS=80", as columns x rows.
+func winsize(fields []string) string {
+	var cols, rows string
+	for _, f := range fields {
+		if v, ok := strings.CutPrefix(f, "COLS="); ok {
+			cols = v
+		}
+		if v, ok := strings.CutPrefix(f, "ROWS="); ok {
+			rows = v
+		}
+	}
+	return cols + "x" + rows
+}
+
+// ttyrecHeader is the size of the header of a ttyrec frame: seconds,
+// microseconds, and length, as little endian 32 bit integers.
+const ttyrecHeader = 12
+
+// isTtyrec reports whether the input is a whole ttyrec recording.
+func isTtyrec(in []byte) bool {
+	_, _, ok := readTtyrec(in)
+	return ok
+}
+
+// readTtyrec reads the frames of a ttyrec recording and the time of the first
+// one, and reports whether the input is one: the frames must cover it
+// exactly.
+func readTtyrec(in []byte) ([]chunk, time.Time, bool) {
+	var chunks []chunk
+	var start time.Time
+	for len(in) > 0 {
+		if len(in) < ttyrecHeader {
+			return nil, start, false
+		}
+		sec := binary.LittleEndian.Uint32(in[0:])
+		usec := binary.LittleEndian.Uint32(in[4:])
+		size := binary.LittleEndian.Uint32(in[8:])
+		if usec >= uint32(time.Second/time.Microsecond) || uint64(size) > uint64(len(in)-ttyrecHeader) {
+			return nil, start, false
+		}
+		t := time.Unix(int64(sec), int64(usec)*int64(time.Microsecond))
+		if len(chunks) == 0 {
+			start = t
+		}
+		in = in[ttyrecHeader:]
+		chunks = append(chunks, chunk{stream: streamOutput, at: t.Sub(start), data: in[:size]})
+		in = in[size:]
+	}
+	return chunks, start, len(chunks) > 0
+}
@@ -0,0 +1,68 @@
+package sequin
+
+import "github.com/charmbracelet/x/ansi"
+
+// Modes whose state changes how other sequences are explained.
+const (
+	modeCursorKeys    = 1
+	modeCursorVisible = 25
+	modeLeftRight     = 69 // DECLRMM
+)
+
+// Character sets that can be designated into G0-G3.
+const (
+	charsetASCII       = 'B'
+	charsetUK          = 'A'
+	charsetLineDrawing = '0'
+)
+
+// session holds the terminal state accumulated while processing a stream, so
+// handlers can explain a sequence according to the modes that are active at
+// that point.
+type session struct {
+	// modes holds the private (DEC) modes set or reset so far.
+	modes map[int]bool
+
+	// kittyFlags is the stack of Kitty keyboard flags pushed so far.
+	kittyFlags []int
+
+	// charsets holds the character sets designated into G0-G3, and gl the
+	// one currently invoked into GL.
+	charsets [4]byte
+	gl       int
+}
+
+func newSession() *session {
+	return &session{
+		modes:    map[int]bool{},
+		charsets: [4]byte{charsetASCII, charsetASCII, charsetASCII, charsetASCII},
+	}
+}
+
+// kittyKeyboard reports whether the Kitty keyboard protocol was negotiated.
+func (s *session) kittyKeyboard() bool {
+	return len(s.kittyFlags) > 0 && s.kittyFlags[len(s.kittyFlags)-1] != 0
+}
+
+// charset returns the character set currently invoked into GL.
+func (s *session) charset() byte {
+	return s.charsets[s.gl]
+}
+
+// control updates the session with the given control code.
+func (s *session) control(c byte) {
+	switch c {
+	case ansi.SO:
+		s.gl = 1
+	case ansi.SI:
+		s.gl = 0
+	}
+}
+
+// softReset resets the state affected by DECSTR.
+func (s *session) softReset() {
+	s.modes[modeCursorKeys] = false
+	s.modes[modeCursorVisible] = true
+	s.charsets = [4]byte{charsetASCII, charsetASCII, charsetASCII, charsetASCII}
+	s.gl = 0
+}
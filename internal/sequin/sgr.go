@@ -1,4 +1,4 @@
-package main
+package sequin
 
 import (
 	"fmt"
@@ -8,11 +8,14 @@ import (
 	"github.com/charmbracelet/x/ansi"
 )
 
+func handleSgr(_ *session, p *ansi.Parser) (string, error) { //nolint:unparam
+	return descSgr(p.Params()), nil
+}
+
 //nolint:mnd
-func handleSgr(p *ansi.Parser) (string, error) { //nolint:unparam
-	params := p.Params()
+func descSgr(params ansi.Params) string {
 	if len(params) == 0 {
-		return "Reset style", nil
+		return "Reset style"
 	}
 
 	var str string
@@ -107,7 +110,7 @@ func handleSgr(p *ansi.Parser) (string, error) { //nolint:unparam
 		}
 	}
 
-	return str, nil
+	return str
 }
 
 var basicColors = map[int]string{
@@ -0,0 +1,178 @@
+package sequin
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/charmbracelet/colorprofile"
+	"github.com/spf13/cobra"
+)
+
+var (
+	summary bool
+	topN    int
+)
+
+func statsCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "stats [file]",
+		Short: "Summarize the sequences in the input",
+		Args:  cobra.MaximumNArgs(1),
+		Example: `
+# Compare the output of two releases of a TUI:
+sequin stats ./testdata/v1.golden
+sequin stats ./testdata/v2.golden
+	`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, err := readInput(cmd, args)
+			if err != nil {
+				return err
+			}
+			f, err := newFilter()
+			if err != nil {
+				return err
+			}
+			var s stats
+			decode(in, func(e event) {
+				if f.keep(e) {
+					s.add(e)
+				}
+			})
+			w := colorprofile.NewWriter(cmd.OutOrStdout(), os.Environ())
+			s.print(w, newTheme())
+			return nil
+		},
+	}
+	return c
+}
+
+const defaultTopN = 10
+
+// stats summarizes the events of a stream.
+type stats struct {
+	events, problems int
+
+	// textBytes and seqBytes count the bytes spent on printable text and on
+	// sequences and control codes.
+	textBytes, seqBytes int
+
+	kinds    map[string]*count
+	handlers map[string]*count
+	seqs     map[string]*count
+}
+
+// count is the number of occurrences of, and bytes spent on, something.
+type count struct {
+	key   string
+	desc  string
+	n     int
+	bytes int
+}
+
+func (s *stats) add(e event) {
+	if s.kinds == nil {
+		s.kinds = map[string]*count{}
+		s.handlers = map[string]*count{}
+		s.seqs = map[string]*count{}
+	}
+
+	s.events++
+	if e.problem() {
+		s.problems++
+	}
+
+	kind := e.kind
+	if kind == "" {
+		kind = unknown
+	}
+	inc(s.kinds, kind, "", len(e.seq))
+
+	if kind == "Text" {
+		s.textBytes += len(e.seq)
+		return
+	}
+	s.seqBytes += len(e.seq)
+
+	switch {
+	case e.name != "":
+		inc(s.handlers, e.name, "", len(e.seq))
+	case kind == "CSI", kind == "DCS", kind == "OSC", kind == "ESC":
+		inc(s.handlers, "(unhandled)", "", len(e.seq))
+	}
+	inc(s.seqs, kind+" "+seqString(e.seq), e.explanation(), len(e.seq))
+}
+
+func inc(m map[string]*count, key, desc string, bytes int) {
+	c, ok := m[key]
+	if !ok {
+		c = &count{key: key, desc: desc}
+		m[key] = c
+	}
+	c.n++
+	c.bytes += bytes
+}
+
+// sorted returns the counts by descending number of occurrences.
+func sorted(m map[string]*count) []*count {
+	counts := make([]*count, 0, len(m))
+	for _, c := range m {
+		counts = append(counts, c)
+	}
+	slices.SortFunc(counts, func(a, b *count) int {
+		if n := cmp.Compare(b.n, a.n); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.key, b.key)
+	})
+	return counts
+}
+
+// print writes the summary to w.
+func (s stats) print(w io.Writer, t theme) {
+	heading := func(title string) {
+		_, _ = fmt.Fprintln(w, t.explanation.Bold(true).Render(title))
+	}
+	row := func(n int, label, desc string) {
+		_, _ = fmt.Fprint(w, t.explanation.Render(fmt.Sprintf("%8d  %s", n, label)))
+		if desc != "" {
+			_, _ = fmt.Fprint(w, t.separator, t.sequence.Render(desc))
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+	percent := func(n int) string {
+		total := s.textBytes + s.seqBytes
+		if total == 0 {
+			return "0%"
+		}
+		return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total)) //nolint:mnd
+	}
+
+	heading("Events")
+	row(s.events, "total", "")
+	row(s.problems, "unknown or invalid", "")
+
+	heading("Bytes")
+	row(s.seqBytes, "sequences and control codes", percent(s.seqBytes))
+	row(s.textBytes, "printable text", percent(s.textBytes))
+
+	heading("Kinds")
+	for _, c := range sorted(s.kinds) {
+		row(c.n, c.key, fmt.Sprintf("%d bytes", c.bytes))
+	}
+
+	heading("Handlers")
+	for _, c := range sorted(s.handlers) {
+		row(c.n, c.key, fmt.Sprintf("%d bytes", c.bytes))
+	}
+
+	heading(fmt.Sprintf("Top %d sequences", topN))
+	for i, c := range sorted(s.seqs) {
+		if i >= topN {
+			break
+		}
+		row(c.n, c.key, c.desc)
+	}
+}
@@ -0,0 +1,288 @@
+package sequin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+	"github.com/charmbracelet/x/xpty"
+)
+
+var teeFile string
+
+var errTeeCommand = errors.New("--tee needs a command to run")
+
+// traceLog is where the streams of a running command are explained, in the
+// order they are written, with the time since start.
+type traceLog struct {
+	mu     sync.Mutex
+	start  time.Time
+	last   time.Duration
+	fn     func(event)
+	closed bool
+}
+
+func newTraceLog(fn func(event)) *traceLog {
+	return &traceLog{start: time.Now(), fn: fn}
+}
+
+// emit calls fn with the event, timed now, unless the log is closed. The
+// lock is held.
+func (l *traceLog) emit(e event) {
+	if l.closed {
+		return
+	}
+	e.at = time.Since(l.start)
+	e.gap, l.last = e.at-l.last, e.at
+	l.fn(e)
+}
+
+// meta explains a chunk that isn't part of a stream, like a resize.
+func (l *traceLog) meta(stream, data string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := metaEvent(chunk{stream: stream, data: []byte(data)}); ok {
+		l.emit(e)
+	}
+}
+
+// close drops whatever is written to the log from now on, so it can't reach
+// a writer that is closed.
+func (l *traceLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+}
+
+// tracer explains one stream of a command as it is written. A sequence
+// split between writes is explained once it is whole.
+type tracer struct {
+	log     *traceLog
+	stream  string
+	sess    *session
+	pending []byte
+	offset  int
+
+	// The pending bytes are scanned once: scanned of them are, and whole
+	// of those end a sequence. state is where the scan stopped.
+	scanned int
+	whole   int
+	state   byte
+}
+
+func newTracer(log *traceLog, stream string) *tracer {
+	return &tracer{log: log, stream: stream, sess: newSession()}
+}
+
+func (tr *tracer) Write(p []byte) (int, error) {
+	tr.log.mu.Lock()
+	defer tr.log.mu.Unlock()
+	if tr.log.closed {
+		return len(p), nil
+	}
+	tr.pending = append(tr.pending, p...)
+	tr.explain(tr.complete())
+	return len(p), nil
+}
+
+// Flush explains what is left of the stream, even if it was cut short.
+func (tr *tracer) Flush() {
+	tr.log.mu.Lock()
+	defer tr.log.mu.Unlock()
+	tr.explain(len(tr.pending))
+}
+
+// explain explains the first n pending bytes. The lock is held.
+func (tr *tracer) explain(n int) {
+	tr.sess.decode(tr.pending[:n], func(e event) {
+		e.offset += tr.offset
+		e.stream = tr.stream
+		tr.log.emit(e)
+	})
+	tr.offset += n
+	tr.pending = append(tr.pending[:0], tr.pending[n:]...)
+	tr.scanned, tr.whole = max(0, tr.scanned-n), max(0, tr.whole-n)
+	if tr.scanned == 0 {
+		tr.state = ansi.NormalState
+	}
+}
+
+// complete returns how many of the pending bytes don't end in the middle of
+// a sequence or a character. Only the bytes written since the last call are
+// scanned.
+func (tr *tracer) complete() int {
+	b := tr.pending
+	for tr.scanned < len(b) {
+		_, _, size, state := ansi.DecodeSequence(b[tr.scanned:], tr.state, nil)
+		tr.scanned, tr.state = tr.scanned+size, state
+		if state == ansi.NormalState {
+			tr.whole = tr.scanned
+		}
+	}
+	n := tr.whole
+	for i := 1; i < utf8.UTFMax && i <= n; i++ {
+		if utf8.RuneStart(b[n-i]) {
+			if !utf8.FullRune(b[n-i : n]) {
+				n -= i
+			}
+			break
+		}
+	}
+	return n
+}
+
+// tee runs the command on our terminal, passing its input, output, and
+// resizes through unchanged, and explains them in --tee as they happen. The
+// error is an *exec.ExitError if the command failed.
+//
+//nolint:wrapcheck,cyclop,funlen
+func tee(ctx context.Context, args []string) error {
+	t, err := newTerminal()
+	if err != nil {
+		return err
+	}
+	f, err := newFilter()
+	if err != nil {
+		return err
+	}
+
+	// Opening a named pipe waits for its reader.
+	out, err := os.OpenFile(teeFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644) //nolint:mnd
+	if err != nil {
+		return err
+	}
+	defer out.Close() //nolint:errcheck
+	w := colorprofile.NewWriter(out, os.Environ())
+	// The log is read on another terminal, and asking ours for its
+	// background would take keys meant for the command.
+	theme := themeFor(func() bool { return true })
+	theme.IsRaw = raw
+	var o printOptions
+
+	pty, err := xpty.NewPty(t.width, t.height)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = pty.Close()
+	}()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint: gosec
+	cmd.Env = t.env
+	var stderr, stderrW *os.File
+	if t.stderr {
+		stderr, stderrW, err = os.Pipe()
+		if err != nil {
+			return err
+		}
+		defer stderr.Close() //nolint:errcheck
+		cmd.Stderr = stderrW
+	}
+
+	var problems int
+	l := newTraceLog(func(e event) {
+		if e.problem() {
+			problems++
+		}
+		if f.keep(e) {
+			printEvent(w, theme, e, o)
+		}
+	})
+	o = flagOptions(l.start)
+	err = pty.Start(cmd)
+	if stderrW != nil {
+		_ = stderrW.Close()
+	}
+	if err != nil {
+		return err
+	}
+	if u, ok := pty.(*xpty.UnixPty); ok {
+		_ = u.Slave().Close()
+	}
+
+	fd := os.Stdin.Fd()
+	if term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		defer term.Restore(fd, state) //nolint:errcheck
+	}
+
+	tracers := []*tracer{newTracer(l, streamOutput), newTracer(l, streamInput)}
+	var readers sync.WaitGroup
+	pass := func(dst io.Writer, src io.Reader, tr *tracer) {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			_, _ = io.Copy(io.MultiWriter(dst, tr), src)
+		}()
+	}
+	pass(os.Stdout, pty, tracers[0])
+	if stderr != nil {
+		tracers = append(tracers, newTracer(l, streamError))
+		pass(os.Stderr, stderr, tracers[2])
+	}
+	// Reading the input never ends, so it isn't waited for.
+	go func() {
+		_, _ = io.Copy(io.MultiWriter(pty, tracers[1]), os.Stdin)
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+	resized := make(chan os.Signal, 1)
+	notifyResize(resized)
+	defer signal.Stop(resized)
+	go func() {
+		width, height := t.width, t.height
+		for {
+			select {
+			case <-done:
+				return
+			case <-resized:
+			}
+			newWidth, newHeight := termSize()
+			if newWidth == width && newHeight == height || pty.Resize(newWidth, newHeight) != nil {
+				continue
+			}
+			width, height = newWidth, newHeight
+			l.meta(streamResize, fmt.Sprintf("%dx%d", width, height))
+		}
+	}()
+
+	err = xpty.WaitProcess(ctx, cmd)
+	drained := make(chan struct{})
+	go func() {
+		readers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(drainTimeout):
+	}
+
+	for _, tr := range tracers {
+		tr.Flush()
+	}
+	if cmd.ProcessState != nil {
+		l.meta(streamExit, strconv.Itoa(cmd.ProcessState.ExitCode()))
+	}
+	// The input is read until we exit, but what is typed from now on is
+	// for the shell, and the log is about to be closed.
+	l.close()
+	if err == nil && strict && problems > 0 {
+		return fmt.Errorf("%w: %d unknown or invalid sequences", errStrict, problems)
+	}
+	return err
+}
@@ -1,4 +1,4 @@
-package main
+package sequin
 
 import (
 	"bytes"
@@ -9,7 +9,7 @@ import (
 	"github.com/charmbracelet/x/ansi"
 )
 
-func handleTermcap(p *ansi.Parser) (string, error) {
+func handleTermcap(_ *session, p *ansi.Parser) (string, error) {
 	data := p.Data()
 	if len(data) == 0 {
 		return "", errInvalid
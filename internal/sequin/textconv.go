@@ -0,0 +1,42 @@
+package sequin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func textconvCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "textconv [file]",
+		Short: "Write one line per sequence, for diffing golden files with git",
+		Args:  cobra.MaximumNArgs(1),
+		Example: `
+# Show readable diffs of golden files in git:
+echo '*.golden diff=sequin' >>.gitattributes
+git config diff.sequin.textconv 'sequin textconv'
+git diff
+	`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, err := readInput(cmd, args)
+			if err != nil {
+				return err
+			}
+			f, err := newFilter()
+			if err != nil {
+				return err
+			}
+			// The output is meant for diffs, so it's never colored, and has no
+			// offsets, which would change with every edit before them.
+			w := cmd.OutOrStdout()
+			decode(in, func(e event) {
+				if f.keep(e) {
+					_, _ = fmt.Fprintln(w, strings.ReplaceAll(diffLine(e), "\n", `\n`))
+				}
+			})
+			return nil
+		},
+	}
+	return c
+}
@@ -1,4 +1,4 @@
-package main
+package sequin
 
 import (
 	"image/color"
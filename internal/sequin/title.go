@@ -1,4 +1,4 @@
-package main
+package sequin
 
 import (
 	"bytes"
@@ -8,7 +8,7 @@ import (
 )
 
 //nolint:mnd
-func handleTitle(p *ansi.Parser) (string, error) {
+func handleTitle(_ *session, p *ansi.Parser) (string, error) {
 	parts := bytes.Split(p.Data(), []byte{';'})
 	if len(parts) != 2 {
 		// Invalid, ignore
@@ -0,0 +1,83 @@
+package sequin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/charmbracelet/colorprofile"
+)
+
+// Tracer explains a stream as it is written, for the tracing package.
+// Sequences split between writes are explained once they are whole.
+type Tracer = tracer
+
+// TraceOptions are what a text tracer shows of the sequences, and the
+// background it colors them for.
+type TraceOptions struct {
+	Raw       bool
+	Offsets   bool
+	Mnemonics bool
+	Hexdump   bool
+	Light     bool
+}
+
+// NewTextTracer returns a tracer that explains the sequences in log, one per
+// line, as sequin does.
+func NewTextTracer(log io.Writer, o TraceOptions) *Tracer {
+	w := colorprofile.NewWriter(log, os.Environ())
+	t := themeFor(func() bool { return !o.Light })
+	t.IsRaw = o.Raw
+	po := printOptions{raw: o.Raw, offsets: o.Offsets, mnemonics: o.Mnemonics, hexdump: o.Hexdump}
+	return newTracer(newTraceLog(func(e event) {
+		printEvent(w, t, e, po)
+	}), streamOutput)
+}
+
+// NewJSONTracer returns a tracer that explains the sequences in log, as a
+// JSON object per line.
+func NewJSONTracer(log io.Writer) *Tracer {
+	enc := json.NewEncoder(log)
+	return newTracer(newTraceLog(func(e event) {
+		_ = enc.Encode(jsonEvent{
+			Time:        e.at.Seconds(),
+			Offset:      e.offset,
+			Kind:        e.kind,
+			Sequence:    seqString(e.seq),
+			Mnemonic:    e.mnemonic(),
+			Explanation: e.explanation(),
+			Problem:     e.problem(),
+		})
+	}), streamOutput)
+}
+
+// NewSlogTracer returns a tracer that logs the sequences, with their
+// explanation as the message. Unknown and invalid sequences are logged as
+// warnings.
+func NewSlogTracer(logger *slog.Logger) *Tracer {
+	return newTracer(newTraceLog(func(e event) {
+		level := slog.LevelInfo
+		if e.problem() {
+			level = slog.LevelWarn
+		}
+		logger.LogAttrs(context.Background(), level, e.explanation(),
+			slog.Duration("time", e.at),
+			slog.Int("offset", e.offset),
+			slog.String("kind", e.kind),
+			slog.String("sequence", seqString(e.seq)),
+		)
+	}), streamOutput)
+}
+
+// jsonEvent is an event as NewJSONTracer writes it.
+type jsonEvent struct {
+	Time        float64 `json:"time"`
+	Offset      int     `json:"offset"`
+	Kind        string  `json:"kind"`
+	Sequence    string  `json:"sequence"`
+	Mnemonic    string  `json:"mnemonic"`
+	Explanation string  `json:"explanation"`
+	Problem     bool    `json:"problem,omitempty"`
+}
@@ -0,0 +1,140 @@
+package sequin
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+var (
+	unescapeInput bool
+	sequences     []string
+)
+
+var errSequencesCommand = errors.New("-e explains the given sequences, not the output of a command")
+
+// reEscapedESC matches the notations for ESC and CSI that unescape reads,
+// followed by what starts a sequence, so that a lone \e or ^[ in plain text
+// isn't taken for one.
+var reEscapedESC = regexp.MustCompile(`(\\(x1[bB]|0?33|e|E|u001[bB]|U0000001[bB])|\^\[)[\[\]P_^X(=>78]|\\x9[bB]`)
+
+// looksEscaped reports whether the input holds sequences written as escaped
+// text, like \x1b[1m, instead of raw ones.
+func looksEscaped(in []byte) bool {
+	return !bytes.ContainsAny(in, "\x1b\x9b") && reEscapedESC.Match(in)
+}
+
+// unescape returns the input with escaped notations replaced by the bytes
+// they stand for: the escapes of Go, C, JSON, and printf (\x1b, \033, \e,
+// \u001b, \n...), and caret notation for control codes (^[, ^G...). Octal
+// escapes stop before they exceed a byte, at \377. Input that is a single
+// double-quoted Go string, as printed by %q, is unquoted, and so is a shell
+// $'...' string.
+//
+//nolint:mnd,cyclop
+func unescape(in []byte) []byte {
+	trimmed := bytes.TrimSpace(in)
+	quoted := len(trimmed) > 1 && trimmed[0] == '"' && trimmed[len(trimmed)-1] == '"'
+	if s, err := strconv.Unquote(string(trimmed)); quoted && err == nil {
+		return []byte(s)
+	}
+	if bytes.HasPrefix(trimmed, []byte("$'")) && bytes.HasSuffix(trimmed, []byte("'")) && len(trimmed) > 2 {
+		in = trimmed[2 : len(trimmed)-1]
+	}
+
+	out := make([]byte, 0, len(in))
+	for i := 0; i < len(in); i++ {
+		c := in[i]
+		if c == '^' && i+1 < len(in) && (in[i+1] >= '@' && in[i+1] <= '_' || in[i+1] == '?') {
+			// Caret notation: ^[ is ESC, ^? is DEL.
+			out = append(out, in[i+1]^0x40)
+			i++
+			continue
+		}
+		if c != '\\' || i+1 == len(in) {
+			out = append(out, c)
+			continue
+		}
+
+		i++
+		switch e := in[i]; e {
+		case 'e', 'E':
+			out = append(out, 0x1b)
+		case 'a':
+			out = append(out, '\a')
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'v':
+			out = append(out, '\v')
+		case 'x':
+			n, size := parseBase(in[i+1:], 16, 2)
+			if size == 0 {
+				out = append(out, '\\', e)
+				break
+			}
+			out = append(out, byte(n))
+			i += size
+		case 'u', 'U':
+			digits := 4
+			if e == 'U' {
+				digits = 8
+			}
+			n, size := parseBase(in[i+1:], 16, digits)
+			if size != digits {
+				out = append(out, '\\', e)
+				break
+			}
+			out = utf8.AppendRune(out, rune(n))
+			i += size
+		case '0':
+			// Octal as in printf, \0NNN, which also covers \033.
+			n, size := parseOctal(in[i+1:])
+			out = append(out, byte(n))
+			i += size
+		case '1', '2', '3', '4', '5', '6', '7':
+			n, size := parseOctal(in[i:])
+			out = append(out, byte(n))
+			i += size - 1
+		default:
+			// \\, \", \/, and unknown escapes stand for the character itself.
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// parseOctal parses up to three octal digits at the start of b, as long as
+// they fit in a byte, and returns the value and the number of digits read.
+func parseOctal(b []byte) (int, int) {
+	const digits = 3
+	n, size := parseBase(b, 8, digits) //nolint:mnd
+	if n > 0xff {
+		n, size = parseBase(b, 8, digits-1) //nolint:mnd
+	}
+	return n, size
+}
+
+// parseBase parses up to digits digits in the given base at the start of b,
+// and returns the value and the number of digits read.
+func parseBase(b []byte, base, digits int) (int, int) {
+	var n, size int
+	for size < digits && size < len(b) {
+		d, err := strconv.ParseUint(string(b[size]), base, 8)
+		if err != nil {
+			break
+		}
+		n = n*base + int(d)
+		size++
+	}
+	return n, size
+}
@@ -0,0 +1,154 @@
+package sequin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func handleXT(_ *session, p *ansi.Parser) (string, error) {
+	var count int
+	if n, ok := p.Param(0, 0); ok {
+		count = n
+	}
+
+	if count != 0 {
+		return "", errInvalid
+	}
+
+	return "Request XT Version", nil
+}
+
+//nolint:mnd
+var modKeysResources = map[int]string{
+	0: "modifyKeyboard",
+	1: "modifyCursorKeys",
+	2: "modifyFunctionKeys",
+	4: "modifyOtherKeys",
+}
+
+func handleXTModKeys(_ *session, p *ansi.Parser) (string, error) {
+	res, _ := p.Param(0, 0)
+	name, ok := modKeysResources[res]
+	if !ok {
+		return "", errInvalid
+	}
+
+	cmd := ansi.Cmd(p.Command())
+	switch cmd.Prefix() {
+	case '>':
+		// XTMODKEYS - Set/reset key modifier options
+		if v, ok := p.Param(1, -1); ok && v >= 0 {
+			return fmt.Sprintf("Set key modifier option %s to %d", name, v), nil
+		}
+		return fmt.Sprintf("Reset key modifier option %s", name), nil
+	case '?':
+		// XTQMODKEYS - Query key modifier options
+		return fmt.Sprintf("Request key modifier option %s", name), nil
+	}
+	return "", errUnhandled
+}
+
+//nolint:mnd
+var sgrStackAttrs = map[int]string{
+	1:  "bold",
+	2:  "faint",
+	3:  "italic",
+	4:  "underline",
+	5:  "blink",
+	7:  "inverse",
+	8:  "invisible",
+	9:  "crossed-out",
+	21: "double underline",
+	30: "foreground color",
+	31: "background color",
+}
+
+func handleXTSgrStack(_ *session, p *ansi.Parser) (string, error) {
+	cmd := ansi.Cmd(p.Command())
+	switch cmd.Final() {
+	case '{':
+		// XTPUSHSGR - Push video attributes onto stack
+		params := p.Params()
+		if len(params) == 0 {
+			return "Push all video attributes", nil
+		}
+		attrs := make([]string, 0, len(params))
+		for _, param := range params {
+			attr, ok := sgrStackAttrs[param.Param(0)]
+			if !ok {
+				return "", errInvalid
+			}
+			attrs = append(attrs, attr)
+		}
+		return fmt.Sprintf("Push video attributes: %s", strings.Join(attrs, ", ")), nil
+	case '}':
+		// XTPOPSGR - Pop video attributes from stack
+		return "Pop video attributes", nil
+	case '|':
+		// XTREPORTSGR - Report selected graphic rendition
+		return fmt.Sprintf("Request video attributes of rectangle %s", descRect(p, 0)), nil
+	}
+	return "", errUnhandled
+}
+
+func handleXTColorStack(_ *session, p *ansi.Parser) (string, error) {
+	var slot string
+	if n, _ := p.Param(0, 0); n > 0 {
+		slot = fmt.Sprintf(" in slot %d", n)
+	}
+
+	cmd := ansi.Cmd(p.Command())
+	switch cmd.Final() {
+	case 'P':
+		// XTPUSHCOLORS - Push color palette onto stack
+		return "Push color palette" + slot, nil
+	case 'Q':
+		// XTPOPCOLORS - Pop color palette from stack
+		return "Pop color palette" + slot, nil
+	case 'R':
+		// XTREPORTCOLORS - Report color palette stack
+		return "Request color palette stack", nil
+	}
+	return "", errUnhandled
+}
+
+//nolint:mnd
+func handleXTGraphics(_ *session, p *ansi.Parser) (string, error) {
+	// XTSMGRAPHICS - Set or request graphics attribute
+	item, _ := p.Param(0, 0)
+	action, _ := p.Param(1, 0)
+
+	var attr string
+	switch item {
+	case 1:
+		attr = "number of color registers"
+	case 2:
+		attr = "Sixel graphics geometry"
+	case 3:
+		attr = "ReGIS graphics geometry"
+	default:
+		return "", errInvalid
+	}
+
+	switch action {
+	case 1:
+		return "Request " + attr, nil
+	case 2:
+		return "Reset " + attr, nil
+	case 3:
+		values := p.Params()[min(2, len(p.Params())):]
+		if len(values) == 0 {
+			return "", errInvalid
+		}
+		vs := make([]string, 0, len(values))
+		for _, v := range values {
+			vs = append(vs, fmt.Sprintf("%d", v.Param(0)))
+		}
+		return fmt.Sprintf("Set %s to %s", attr, strings.Join(vs, "x")), nil
+	case 4:
+		return "Request maximum " + attr, nil
+	}
+	return "", errInvalid
+}
@@ -0,0 +1,110 @@
+// Package tracing explains the ANSI sequences a program writes to its
+// terminal, from within the program, as sequin does.
+package tracing
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/charmbracelet/sequin/internal/sequin"
+)
+
+// Writer passes what is written to it on to another writer, and explains the
+// sequences in it as they go. Sequences split between writes are explained
+// once they are whole. It is safe for concurrent use: each write is passed on
+// and explained before the next one starts.
+//
+// When the writer it wraps is a terminal, a Writer passes its file descriptor
+// on, so programs like Bubble Tea can use it as their output.
+type Writer struct {
+	mu  sync.Mutex
+	dst io.Writer
+	tr  *sequin.Tracer
+}
+
+// Options are what NewWriter shows of the sequences.
+type Options struct {
+	// Raw shows the sequences as they were written, colored by kind,
+	// instead of explaining them.
+	Raw bool
+
+	// Offsets shows where each sequence starts in the output, and its
+	// length.
+	Offsets bool
+
+	// Mnemonics shows the sequences as mnemonics with arguments, like
+	// SGR(bold, fg=red), instead of explaining them.
+	Mnemonics bool
+
+	// Hexdump shows the bytes of each sequence, in the style of xxd.
+	Hexdump bool
+
+	// Light colors the log for a light background. It is colored for a
+	// dark one by default.
+	Light bool
+}
+
+// NewWriter returns a writer to dst that explains the sequences written to
+// log, one per line, as sequin does.
+func NewWriter(dst, log io.Writer, o Options) *Writer {
+	return &Writer{dst: dst, tr: sequin.NewTextTracer(log, sequin.TraceOptions(o))}
+}
+
+// NewJSONWriter returns a writer to dst that explains the sequences written
+// to log, as a JSON object per line. The objects have the time since the
+// writer was made in seconds, and the offset, kind, sequence, mnemonic, and
+// explanation of the sequence. Unknown and invalid sequences are marked as a
+// problem.
+func NewJSONWriter(dst, log io.Writer) *Writer {
+	return &Writer{dst: dst, tr: sequin.NewJSONTracer(log)}
+}
+
+// NewSlogWriter returns a writer to dst that logs the sequences written, with
+// their explanation as the message. Unknown and invalid sequences are logged
+// as warnings.
+func NewSlogWriter(dst io.Writer, logger *slog.Logger) *Writer {
+	return &Writer{dst: dst, tr: sequin.NewSlogTracer(logger)}
+}
+
+// Write writes p to the wrapped writer, and explains what was written.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.dst.Write(p)
+	_, _ = w.tr.Write(p[:n])
+	return n, err //nolint:wrapcheck
+}
+
+// Flush explains what is left of a sequence cut short.
+func (w *Writer) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tr.Flush()
+}
+
+// Read reads from the wrapped writer, if it can be read from.
+func (w *Writer) Read(p []byte) (int, error) {
+	if r, ok := w.dst.(io.Reader); ok {
+		return r.Read(p) //nolint:wrapcheck
+	}
+	return 0, io.EOF
+}
+
+// Close flushes the writer, and closes the wrapped one if it can be closed.
+func (w *Writer) Close() error {
+	w.Flush()
+	if c, ok := w.dst.(io.Closer); ok {
+		return c.Close() //nolint:wrapcheck
+	}
+	return nil
+}
+
+// Fd returns the file descriptor of the wrapped writer, or an invalid one if
+// it has none.
+func (w *Writer) Fd() uintptr {
+	if f, ok := w.dst.(interface{ Fd() uintptr }); ok {
+		return f.Fd()
+	}
+	return ^uintptr(0)
+}
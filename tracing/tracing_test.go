@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// event is an event as NewJSONWriter writes it.
+type event struct {
+	Time        float64 `json:"time"`
+	Offset      int     `json:"offset"`
+	Kind        string  `json:"kind"`
+	Sequence    string  `json:"sequence"`
+	Mnemonic    string  `json:"mnemonic"`
+	Explanation string  `json:"explanation"`
+	Problem     bool    `json:"problem,omitempty"`
+}
+
+func TestWriter(t *testing.T) {
+	in := "\x1b[1mhi\x1b[m\x1b[999z"
+
+	t.Run("json", func(t *testing.T) {
+		var dst, log bytes.Buffer
+		w := NewJSONWriter(&dst, &log)
+		for _, part := range []string{in[:3], in[3:8], in[8:]} {
+			_, err := io.WriteString(w, part)
+			require.NoError(t, err)
+		}
+		require.Equal(t, in, dst.String())
+
+		var got []event
+		dec := json.NewDecoder(&log)
+		for dec.More() {
+			var e event
+			require.NoError(t, dec.Decode(&e))
+			e.Time = 0
+			got = append(got, e)
+		}
+		require.Equal(t, []event{
+			{Offset: 0, Kind: "CSI", Sequence: "1m", Mnemonic: "SGR(bold)", Explanation: "Bold"},
+			{Offset: 4, Kind: "Text", Sequence: "hi", Mnemonic: `"hi"`, Explanation: "hi"},
+			{Offset: 6, Kind: "CSI", Sequence: "m", Mnemonic: "SGR", Explanation: "Reset style"},
+			{Offset: 9, Kind: "CSI", Sequence: "999z", Mnemonic: `CSI "999z"`, Explanation: "unrecognized variant of DECERA", Problem: true},
+		}, got)
+	})
+
+	t.Run("slog", func(t *testing.T) {
+		var dst, log bytes.Buffer
+		w := NewSlogWriter(&dst, slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey || a.Key == "time" {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})))
+		_, err := io.WriteString(w, in)
+		require.NoError(t, err)
+		require.Equal(t, in, dst.String())
+		require.Equal(t, strings.Join([]string{
+			`level=INFO msg=Bold offset=0 kind=CSI sequence=1m`,
+			`level=INFO msg=hi offset=4 kind=Text sequence=hi`,
+			`level=INFO msg="Reset style" offset=6 kind=CSI sequence=m`,
+			`level=WARN msg="unrecognized variant of DECERA" offset=9 kind=CSI sequence=999z`,
+		}, "\n")+"\n", log.String())
+	})
+
+	t.Run("text", func(t *testing.T) {
+		var dst, log bytes.Buffer
+		w := NewWriter(&dst, &log, Options{Offsets: true, Mnemonics: true})
+		_, err := io.WriteString(w, in)
+		require.NoError(t, err)
+		require.Equal(t, in, dst.String())
+		// Each line starts with the time of the sequence.
+		got := regexp.MustCompile(`(?m)^ *[0-9.]+s +\+[0-9.]+s out `).ReplaceAllString(log.String(), "")
+		require.Equal(t, strings.Join([]string{
+			`00000000+4   SGR(bold)`,
+			`00000004+2   "hi"`,
+			`00000006+3   SGR`,
+			`00000009+6   CSI "999z"`,
+		}, "\n")+"\n", got)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// Each write is explained whole, and in the order it was passed on.
+		var dst, log bytes.Buffer
+		w := NewJSONWriter(&dst, &log)
+		var wg sync.WaitGroup
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = io.WriteString(w, in)
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, strings.Repeat(in, 50), dst.String())
+		require.Equal(t, 50*4, strings.Count(log.String(), "\n"))
+	})
+
+	t.Run("fd", func(t *testing.T) {
+		require.Equal(t, os.Stdout.Fd(), NewWriter(os.Stdout, io.Discard, Options{}).Fd())
+		require.Equal(t, ^uintptr(0), NewWriter(io.Discard, io.Discard, Options{}).Fd())
+	})
+}